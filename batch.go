@@ -0,0 +1,107 @@
+package headlessChrome
+
+import (
+	"sort"
+	"strings"
+	"sync"
+)
+
+// URLErrors holds the errors from MapURLs keyed by the url that failed
+type URLErrors map[string]error
+
+// Error implements the error interface
+func (e URLErrors) Error() string {
+	urls := make([]string, 0, len(e))
+	for url := range e {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	msgs := make([]string, 0, len(urls))
+	for _, url := range urls {
+		msgs = append(msgs, url+": "+e[url].Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// MapURLs loads each url and runs fn against a session pointed at it,
+// using at most concurrency chrome processes at once.  Each process is
+// reused for several urls by navigating it, and is replaced if loading a
+// url fails.  The results are returned in the same order as the urls.  If
+// any url fails, its result is nil and a URLErrors holding every failure
+// is returned alongside the results that succeeded.
+func MapURLs(urls []string, concurrency int, fn func(*ChromeSession) (interface{}, error), opts ...Option) ([]interface{}, error) {
+	return mapURLs(urls, concurrency, fn, func(url string) (*ChromeSession, error) {
+		return NewBrowser(url, opts...)
+	})
+}
+
+// mapURLs implements MapURLs, starting each worker's session with start
+func mapURLs(urls []string, concurrency int, fn func(*ChromeSession) (interface{}, error), start func(url string) (*ChromeSession, error)) ([]interface{}, error) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]interface{}, len(urls))
+	failures := URLErrors{}
+	var failuresLock sync.Mutex
+	fail := func(url string, err error) {
+		failuresLock.Lock()
+		defer failuresLock.Unlock()
+		failures[url] = err
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var browser *ChromeSession
+			defer func() {
+				if browser != nil {
+					browser.Exit()
+				}
+			}()
+
+			for idx := range jobs {
+				url := urls[idx]
+				var err error
+				if browser == nil {
+					browser, err = start(url)
+					if err != nil {
+						browser = nil
+					}
+				} else {
+					err = browser.Navigate(url)
+					if err != nil {
+						browser.Exit()
+						browser = nil
+					}
+				}
+				if err != nil {
+					fail(url, err)
+					continue
+				}
+
+				result, err := fn(browser)
+				if err != nil {
+					fail(url, err)
+					continue
+				}
+				results[idx] = result
+			}
+		}()
+	}
+
+	for idx := range urls {
+		jobs <- idx
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(failures) > 0 {
+		return results, failures
+	}
+	return results, nil
+}
@@ -0,0 +1,72 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// navigateTarget pulls the url out of the expression Navigate writes
+var navigateTarget = regexp.MustCompile(`location\.href=("[^"]*")`)
+
+// newFakeBrowser starts a fake session that follows Navigate and answers
+// location.href with the url it was last pointed at
+func newFakeBrowser(t *testing.T, url string) *ChromeSession {
+	var lock sync.Mutex
+	current := url
+	return newFakeSession(t, newFakeConsole(func(input string) []string {
+		lock.Lock()
+		defer lock.Unlock()
+		if input == "location.href" {
+			b, _ := json.Marshal(current)
+			return []string{`{"result":{"type":"string","value":` + string(b) + `}}`}
+		}
+		if m := navigateTarget.FindStringSubmatch(input); m != nil {
+			json.Unmarshal([]byte(m[1]), &current)
+		}
+		return []string{`{"result":{"type":"boolean","value":true}}`}
+	}))
+}
+
+// TestMapURLs tests that results keep the order of the urls and that
+// failures to start or in fn are collected with nil results
+func TestMapURLs(t *testing.T) {
+	urls := []string{"https://fail-start.example", "https://a.example", "https://fn-error.example", "https://b.example", "https://c.example"}
+	start := func(url string) (*ChromeSession, error) {
+		if url == "https://fail-start.example" {
+			return nil, errors.New("chrome did not start")
+		}
+		return newFakeBrowser(t, url), nil
+	}
+	fn := func(cs *ChromeSession) (interface{}, error) {
+		href, err := cs.Eval("location.href")
+		if err != nil {
+			return nil, err
+		}
+		if strings.Contains(href, "fn-error") {
+			return "partial", errors.New("scrape failed")
+		}
+		return href, nil
+	}
+
+	results, err := mapURLs(urls, 2, fn, start)
+
+	want := []interface{}{nil, "https://a.example", nil, "https://b.example", "https://c.example"}
+	if !reflect.DeepEqual(results, want) {
+		t.Fatalf("results = %v, want %v", results, want)
+	}
+	var failures URLErrors
+	if !errors.As(err, &failures) || len(failures) != 2 {
+		t.Fatalf("err = %v, want URLErrors for two urls", err)
+	}
+	if failures["https://fail-start.example"] == nil || failures["https://fn-error.example"] == nil {
+		t.Fatalf("failures = %v, want the start and fn failures", failures)
+	}
+	if want := "https://fail-start.example: chrome did not start; https://fn-error.example: scrape failed"; err.Error() != want {
+		t.Fatalf("Error() = %q, want %q", err.Error(), want)
+	}
+}
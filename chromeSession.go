@@ -3,10 +3,19 @@
 package headlessChrome
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"os/exec"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/integrii/interactive"
@@ -19,6 +28,12 @@ var Debug bool
 // before we consider it a failure
 var BrowserStartupTime = time.Second * 20
 
+// ExitTimeout is how long Exit and ForceClose wait for chrome's output to
+// end before killing it, and then how long they wait after the kill
+// before giving up on the output.  Giving up closes the session even if
+// the process can not be reaped, so readers of Output are never stuck.
+var ExitTimeout = time.Second * 10
+
 // ChromePath is the command to execute chrome
 var ChromePath = ChromePathMacOS
 
@@ -39,54 +54,429 @@ var Args = []string{
 	// "--verbose",
 }
 
+// ErrStartupTimeout is returned when the chrome console does not become ready
+// before the startup timeout expires
+var ErrStartupTimeout = errors.New("Chrome console failed to init in the alotted time")
+
+// ErrSessionClosed is returned when reading from a session whose chrome
+// process has exited and whose output has been fully read
+var ErrSessionClosed = errors.New("chrome session is closed")
+
+// StartupError is returned by NewBrowser and NewBrowserWithTimeout when
+// chrome fails to start.  It carries everything needed to reproduce the
+// launch and can be extracted with errors.As.  The exit code of chrome is
+// not included because the process is owned by the interactive package.
+type StartupError struct {
+	Path   string   // the chrome executable that was run
+	Args   []string // the arguments chrome was started with
+	Output []string // lines chrome printed before startup failed
+	Err    error    // the underlying cause, such as ErrStartupTimeout
+}
+
+// Error implements the error interface
+func (e *StartupError) Error() string {
+	msg := "failed to start chrome at " + e.Path + ": " + e.Err.Error()
+	if cause := e.Cause(); cause != "" {
+		msg += "; chrome reported: " + cause
+	}
+	if len(e.Output) > 0 {
+		msg += "; chrome output: " + strings.Join(e.Output, "\n")
+	}
+	return msg
+}
+
+// Unwrap returns the underlying cause of the startup failure
+func (e *StartupError) Unwrap() error {
+	return e.Err
+}
+
+// startupErrorMarkers are pieces of chrome and V8 output that explain a
+// failed startup, from most to least specific.  Chrome logs harmless
+// errors on many systems, such as failing to reach dbus, so page and
+// network errors are preferred over general log errors.
+var startupErrorMarkers = [][]string{
+	{"net::ERR_", "Uncaught", "SyntaxError", "TypeError", "ReferenceError"},
+	{":FATAL:", "Check failed:"},
+	{":ERROR:", "ERROR:"},
+}
+
+// Cause returns the line of Output that most likely explains why chrome
+// failed to start, such as a net::ERR_ code for a bad url, or "" if no
+// line looks like an error
+func (e *StartupError) Cause() string {
+	for _, markers := range startupErrorMarkers {
+		for _, line := range e.Output {
+			for _, marker := range markers {
+				if strings.Contains(line, marker) {
+					return strings.TrimSpace(line)
+				}
+			}
+		}
+	}
+	return ""
+}
+
 const expectedFirstLine = `Type a Javascript expression to evaluate or "quit" to exit.`
 const promptPrefix = `>>>`
 
 // outputSanitizer puts output coming from the consolw that
 // does not begin with the input prompt into the session
-// output channel
+// output channel and reports any crashes it sees.  Lines before the console banner are kept
+// aside for startup errors instead.  The done channel, and the
+// output channel if the session owns it, are closed once the
+// console output ends, and the session is no longer tracked.
 func (cs *ChromeSession) outputSanitizer() {
-	for text := range cs.Session.Output {
-		debug("raw output:", text)
-		if !strings.HasPrefix(text, promptPrefix) {
-			cs.Output <- text
+	defer cs.setState(StateDead)
+	defer close(cs.done)
+	defer untrackSession(cs) // chrome may have exited without Exit being called
+	if cs.closeOutput {
+		defer close(cs.Output)
+	}
+	if cs.Crashes != nil {
+		defer close(cs.Crashes)
+	}
+
+	var ready bool
+	lines := cs.console.Lines()
+	for {
+		var text string
+		var ok bool
+		select {
+		case text, ok = <-lines:
+		case <-cs.abandon:
+			cs.debug("WARNING: gave up waiting for chrome's output to end")
+			return
+		}
+		if !ok {
+			return
+		}
+		cs.debug("raw output:", text)
+		if event, crashed := detectCrash(text); crashed && cs.Crashes != nil {
+			cs.reportCrash(event)
+		}
+		if cs.protocol().IsPrompt(text) {
+			continue
+		}
+		if !ready {
+			if cs.protocol().IsBanner(text) {
+				ready = true
+				cs.setState(StateReady)
+				close(cs.ready)
+				continue
+			}
+			cs.debug("WARNING: Unespected first line when initializing headless Chrome console:", text)
+			cs.startupLock.Lock()
+			cs.startupOutput = append(cs.startupOutput, text)
+			cs.startupLock.Unlock()
+			continue
+		}
+		if atomic.LoadInt32(&cs.paused) == 1 {
+			if _, isResult := cs.protocol().ParseResult(text); !isResult {
+				atomic.AddUint64(&cs.dropped, 1)
+				continue
+			}
+		}
+		cs.sendOutput(text)
+	}
+}
+
+// sendOutput puts a line on the output channel, following the session's
+// overflow policy when the channel is full
+func (cs *ChromeSession) sendOutput(text string) {
+	switch cs.overflow {
+	case OverflowDropNewest:
+		select {
+		case cs.Output <- text:
+		default:
+			atomic.AddUint64(&cs.dropped, 1)
 		}
+	case OverflowDropOldest:
+		for {
+			select {
+			case cs.Output <- text:
+				return
+			default:
+			}
+			select {
+			case <-cs.Output:
+				atomic.AddUint64(&cs.dropped, 1)
+			default:
+			}
+		}
+	default:
+		cs.Output <- text
 	}
 }
 
+// PauseOutput stops forwarding output to the Output channel, which keeps
+// noisy pages from filling it.  Lines are dropped while paused and counted
+// by DroppedLines.  Expression results are still forwarded so Eval keeps
+// working.
+func (cs *ChromeSession) PauseOutput() {
+	atomic.StoreInt32(&cs.paused, 1)
+}
+
+// ResumeOutput starts forwarding output to the Output channel again after
+// PauseOutput
+func (cs *ChromeSession) ResumeOutput() {
+	atomic.StoreInt32(&cs.paused, 0)
+}
+
+// DroppedLines returns how many lines of output have been dropped because
+// output was paused or the Output channel overflowed
+func (cs *ChromeSession) DroppedLines() uint64 {
+	return atomic.LoadUint64(&cs.dropped)
+}
+
+// startupLines returns the lines chrome printed before its console banner
+func (cs *ChromeSession) startupLines() []string {
+	cs.startupLock.Lock()
+	defer cs.startupLock.Unlock()
+	return append([]string{}, cs.startupOutput...)
+}
+
 // ChromeSession is an interactive console Session with a Chrome
 // instance.
 type ChromeSession struct {
+	dropped uint64 // lines dropped while paused, first for 64-bit atomic alignment
+	paused  int32  // 1 while output is paused
+
 	Session *interactive.Session
+	console console // talks to Session, or to a fake in tests
 	Output  chan string
 	Input   chan string
+
+	// Crashes receives an event when chrome reports a crash in its
+	// output.  It is closed when the session ends.
+	Crashes chan CrashEvent
+
+	evalLock     sync.Mutex    // ensures only one Eval waits on Output at a time
+	closeOnce    sync.Once     // makes Exit and ForceClose safe to call more than once
+	staleResults int           // results of abandoned evals still to be skipped
+	unsynced     bool          // Write was called, so Output may hold results no Eval asked for
+	syncCount    int           // numbers the markers written to resync with the console
+	tempDir      string        // temporary profile directory removed on exit
+	path         string        // the chrome executable that was started
+	args         []string      // the arguments chrome was started with
+	opts         []Option      // the options the session was started with
+	name         string        // tags debug output from this session
+	poll         time.Duration // how often waiting helpers check the page
+	lifetime     *time.Timer   // exits the session when its max lifetime is up
+
+	downloadDir   string          // where chrome saves downloads
+	seenDownloads map[string]bool // downloads already returned or present at start
+	debuggingPort int             // the devtools http port, if one was set
+	config        SessionConfig   // the resolved settings, see Config
+	overflow      OverflowPolicy  // what to do when Output is full
+	echo          EchoMode        // what Eval does with an echo of its input
+	trace         io.Writer       // receives a record of each evaluation, if set
+
+	replProtocol REPLProtocol // reads the console output, DefaultREPLProtocol if nil
+
+	keepAliveLock sync.Mutex
+	keepAlive     chan struct{} // closed to stop the running keep-alive, if any
+
+	stateLock sync.Mutex                  // serializes state changes and hook calls
+	state     SessionState                // where the session is in its life
+	stateHook func(from, to SessionState) // called on each state change, if set
+
+	closeOutput   bool          // Output is owned by the session and closed when done
+	ready         chan struct{} // closed when the console banner is seen
+	done          chan struct{} // closed when the console output ends
+	abandon       chan struct{} // closed to stop waiting for output that never ends
+	startupLock   sync.Mutex
+	startupOutput []string // lines printed before the console banner
 }
 
 // Exit exits the running command out by ossuing a 'quit'
-// to the chrome console
+// to the chrome console.  Only the first call to Exit or ForceClose does
+// anything, so it is safe to defer Exit and also call it on error paths.
 func (cs *ChromeSession) Exit() {
-	cs.Session.Write(`;quit`)
-	cs.Session.Exit()  // exit the process with an interrupt signal
-	cs.Session.Close() // close the tty session
+	cs.closeOnce.Do(func() {
+		cs.setState(StateExiting)
+		untrackSession(cs)
+		cs.stopLifetime()
+		if cs.console == nil {
+			// chrome never started, so there is only the profile to clean up
+			cs.removeTempDir()
+			return
+		}
+		cs.console.Write(`;quit`)
+		cs.console.Exit()  // exit the process with an interrupt signal
+		cs.console.Close() // close the tty session
+		cs.removeTempDir()
+		go cs.watchExit(ExitTimeout, false)
+	})
 }
 
-// Write writes to the Session
+// Write writes to the Session.  Once chrome has exited its console can
+// no longer take input, so writes are dropped and Err reports why.  Chrome
+// prints a result for each line written, which is left on Output for the
+// caller.  The next Eval skips over any result that is still unread.
 func (cs *ChromeSession) Write(s string) {
-	debug("write:", s)
-	cs.Session.Write(s)
+	cs.evalLock.Lock()
+	defer cs.evalLock.Unlock()
+	cs.unsynced = true
+	cs.write(s)
+}
+
+// write writes to the console unless chrome has exited
+func (cs *ChromeSession) write(s string) {
+	if cs.Err() != nil {
+		cs.debug("WARNING: dropped write to a closed session:", s)
+		return
+	}
+	cs.debug("write:", s)
+	cs.console.Write(s)
+}
+
+// Err returns ErrSessionClosed once chrome has exited and the session can
+// no longer take input, or nil while it is running
+func (cs *ChromeSession) Err() error {
+	select {
+	case <-cs.done:
+		return ErrSessionClosed
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until chrome's console output has ended and every line of
+// it, including any final error chrome printed to stderr, has been put on
+// the Output channel.  An Output channel owned by the session is closed
+// before Wait returns.  With the default overflow policy lines are only
+// handed over as Output is read, so another goroutine must keep reading
+// Output or Wait can not return until the context is done.
+func (cs *ChromeSession) Wait(ctx context.Context) error {
+	select {
+	case <-cs.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ReadLine returns the next line of output from the session.  It returns
+// ErrSessionClosed once chrome has exited and all output has been read, or
+// the context's error if it is canceled before a line arrives.
+func (cs *ChromeSession) ReadLine(ctx context.Context) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case line, ok := <-cs.Output:
+		if !ok {
+			return "", ErrSessionClosed
+		}
+		return line, nil
+	case <-cs.done:
+		// the output channel may not be ours to close, so pick up any
+		// output still buffered before reporting the session closed
+		select {
+		case line, ok := <-cs.Output:
+			if ok {
+				return line, nil
+			}
+		default:
+		}
+		return "", ErrSessionClosed
+	}
+}
+
+// CommandLine returns the shell quoted command used to start chrome for
+// this session, which can be pasted into a terminal to reproduce it
+func (cs *ChromeSession) CommandLine() string {
+	quoted := make([]string, 0, len(cs.args)+1)
+	quoted = append(quoted, shellQuote(cs.path))
+	for _, arg := range cs.args {
+		quoted = append(quoted, shellQuote(arg))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote quotes a string for use as a single shell word
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	for _, r := range s {
+		isSafe := r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_=+/:.,@%", r)
+		if !isSafe {
+			return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+		}
+	}
+	return s
 }
 
 // outputPrinter prints all outputs from the output channel to the cli
 func (cs *ChromeSession) outputPrinter() {
-	for l := range cs.Session.Output {
-		debug("read:", l)
+	for l := range cs.console.Lines() {
+		cs.debug("read:", l)
 		fmt.Println(l)
 	}
 }
 
-// ForceClose issues a force kill to the command
+// ForceClose issues a force kill to the command.  Like Exit, it does
+// nothing if the session was already closed.
 func (cs *ChromeSession) ForceClose() {
-	cs.Session.ForceClose()
+	cs.closeOnce.Do(func() {
+		cs.setState(StateExiting)
+		untrackSession(cs)
+		cs.stopLifetime()
+		if cs.console == nil {
+			cs.removeTempDir()
+			return
+		}
+		cs.console.ForceClose()
+		cs.removeTempDir()
+		go cs.watchExit(ExitTimeout, true)
+	})
+}
+
+// watchExit makes sure the session ends after chrome was told to exit.
+// If the output has not ended after the timeout, chrome is killed, unless
+// it already was, and if it still has not ended after another timeout the
+// output is abandoned.
+func (cs *ChromeSession) watchExit(timeout time.Duration, killed bool) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		select {
+		case <-cs.done:
+			return
+		case <-timer.C:
+		}
+		if killed {
+			if cs.abandon != nil {
+				close(cs.abandon)
+			}
+			return
+		}
+		cs.debug("WARNING: chrome did not exit within", timeout, "and is being killed")
+		cs.console.ForceClose()
+		killed = true
+		timer.Reset(timeout)
+	}
+}
+
+// stopLifetime cancels the max lifetime timer, if there is one
+func (cs *ChromeSession) stopLifetime() {
+	if cs.lifetime != nil {
+		cs.lifetime.Stop()
+	}
+}
+
+// removeTempDir deletes the temporary profile directory made for this
+// session, if there is one
+func (cs *ChromeSession) removeTempDir() {
+	if cs.tempDir == "" {
+		return
+	}
+	cs.debug("removing temporary profile directory", cs.tempDir)
+	err := os.RemoveAll(cs.tempDir)
+	if err != nil {
+		cs.debug("WARNING: failed to remove temporary profile directory:", err)
+	}
+	cs.tempDir = ""
 }
 
 // ClickSelector calls a click() on the supplied selector
@@ -145,52 +535,216 @@ func (cs *ChromeSession) SetInputTextByClasses(classes string, itemIndex int, te
 	cs.Write(`document.getElementsByClassName("` + classes + `")[` + strconv.Itoa(itemIndex) + `].value = "` + text + `"`)
 }
 
+// BuildCommand assembles the command NewBrowser would run for a url and
+// options, without starting it.  This is useful for testing how options
+// combine or for running chrome some other way.  Options that need a
+// temporary profile still create one, and it is up to the caller to
+// remove the directory given in the --user-data-dir argument.
+func BuildCommand(url string, opts ...Option) (*exec.Cmd, error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
+	_, err := o.prepareProfile()
+	if err != nil {
+		return nil, err
+	}
+	return exec.Command(ChromePath, o.chromeArgs(url)...), nil
+}
+
 // NewBrowserWithTimeout starts a new chrome headless session
 // but limits how long it can run before its killed forcefully.
 // A time limit of 0 means there is not a time limit
-func NewBrowserWithTimeout(url string, timeout time.Duration) (*ChromeSession, error) {
+func NewBrowserWithTimeout(url string, timeout time.Duration, opts ...Option) (*ChromeSession, error) {
 	var err error
-
-	debug("Creating a new browser pointed to", url)
+	o := newOptions(opts)
+	if o.err != nil {
+		return nil, o.err
+	}
 
 	chromeSession := ChromeSession{}
-	chromeSession.Output = make(chan string, 5000)
+	chromeSession.name = o.name
+	chromeSession.replProtocol = o.replProtocol
+	chromeSession.poll = o.pollInterval
+	chromeSession.downloadDir = o.downloadDir
+	chromeSession.overflow = o.overflow
+	chromeSession.echo = o.echo
+	chromeSession.stateHook = o.stateHook
+	chromeSession.trace = o.trace
+	chromeSession.debuggingPort = o.debuggingPort
+	chromeSession.markExistingDownloads()
+	if chromeSession.name == "" {
+		chromeSession.name = randomName()
+	}
+	chromeSession.debug("Creating a new browser pointed to", url)
+
+	chromeSession.Output = o.output
+	chromeSession.closeOutput = o.closeOutput
+	if chromeSession.Output == nil {
+		chromeSession.Output = make(chan string, 5000)
+		chromeSession.closeOutput = true
+	}
+	chromeSession.Crashes = make(chan CrashEvent, crashBufferSize)
+	chromeSession.ready = make(chan struct{})
+	chromeSession.done = make(chan struct{})
+	chromeSession.abandon = make(chan struct{})
+	chromeSession.opts = opts
+
+	// make a throwaway profile for options that need one and
+	// write any preferences into the profile
+	chromeSession.tempDir, err = o.prepareProfile()
+	if err != nil {
+		chromeSession.setState(StateDead)
+		return &chromeSession, &StartupError{Path: ChromePath, Err: err}
+	}
 
 	// add url as last arg and create new Session
-	args := append(Args, url)
-	debug(ChromePath, args)
+	args := o.chromeArgs(url)
+	chromeSession.path = ChromePath
+	chromeSession.args = args
+	chromeSession.config = newSessionConfig(&chromeSession, o)
+	chromeSession.debug(ChromePath, args)
 	chromeSession.Session, err = interactive.NewSessionWithTimeout(ChromePath, args, timeout)
 	if err != nil {
-		return &chromeSession, err
+		chromeSession.removeTempDir()
+		chromeSession.setState(StateDead)
+		return &chromeSession, &StartupError{Path: ChromePath, Args: args, Err: err}
 	}
+	chromeSession.console = interactiveConsole{chromeSession.Session}
+	trackSession(&chromeSession)
 
 	// map output and input channels for easy use
 	chromeSession.Input = chromeSession.Session.Input
 	go chromeSession.outputSanitizer()
 
 	// wait for the console ready line from the browser
-	// and if it does not start in time, throw an error
-	startupTime := time.NewTimer(BrowserStartupTime)
-	for {
-		select {
-		case <-startupTime.C:
-			debug("ERROR: Browser failed to start before browser startup time cutoff")
-			chromeSession.ForceClose() // force cloe the session because it failed
-			err = errors.New("Chrome console failed to init in the alotted time")
-			return &chromeSession, err
-		case line := <-chromeSession.Output:
-			if strings.Contains(line, expectedFirstLine) {
-				debug("Chrome console REPL ready")
-				return &chromeSession, err
+	// and if it does not start in time, throw an error that
+	// includes whatever chrome printed in the meantime
+	startupTime := time.NewTimer(o.startupTimeout)
+	defer startupTime.Stop()
+	select {
+	case <-startupTime.C:
+		chromeSession.debug("ERROR: Browser failed to start before browser startup time cutoff")
+		chromeSession.ForceClose() // force cloe the session because it failed
+		return &chromeSession, &StartupError{Path: ChromePath, Args: args, Output: chromeSession.startupLines(), Err: fmt.Errorf("%w after %v", ErrStartupTimeout, o.startupTimeout)}
+	case <-chromeSession.done:
+		chromeSession.debug("ERROR: Browser exited before the console was ready")
+		chromeSession.ForceClose()
+		return &chromeSession, &StartupError{Path: ChromePath, Args: args, Output: chromeSession.startupLines(), Err: ErrSessionClosed}
+	case <-chromeSession.ready:
+		chromeSession.debug("Chrome console REPL ready")
+		if o.stealth {
+			if err := chromeSession.Exec(stealthScript); err != nil {
+				chromeSession.debug("WARNING: failed to apply stealth overrides:", err)
 			}
-			debug("WARNING: Unespected first line when initializing headless Chrome console:", line)
 		}
+		if o.maxLifetime > 0 {
+			chromeSession.lifetime = time.AfterFunc(o.maxLifetime, func() {
+				chromeSession.debug("WARNING: session reached its max lifetime of", o.maxLifetime, "and is exiting")
+				chromeSession.Exit()
+			})
+		}
+		return &chromeSession, err
 	}
 }
 
 // NewBrowser starts a new chrome headless Session.
-func NewBrowser(url string) (*ChromeSession, error) {
-	return NewBrowserWithTimeout(url, 0)
+func NewBrowser(url string, opts ...Option) (*ChromeSession, error) {
+	return NewBrowserWithTimeout(url, 0, opts...)
+}
+
+// retryBackoff is how long NewBrowserWithRetry waits after the first
+// failed attempt.  The wait doubles after each following attempt.
+var retryBackoff = time.Second
+
+// NewBrowserWithRetry starts a new chrome headless Session, trying up to
+// attempts times with an exponential backoff between tries.  Each failed
+// attempt kills its chrome process before the next one starts.  If every
+// attempt fails, the error from the last one is returned.
+func NewBrowserWithRetry(url string, attempts int, opts ...Option) (*ChromeSession, error) {
+	var chromeSession *ChromeSession
+	var err error
+	backoff := retryBackoff
+	for attempt := 1; attempt <= attempts; attempt++ {
+		chromeSession, err = NewBrowser(url, opts...)
+		if err == nil {
+			return chromeSession, nil
+		}
+		debug("WARNING: browser startup attempt", attempt, "of", attempts, "failed:", err)
+		if attempt < attempts {
+			time.Sleep(backoff)
+			backoff *= 2
+		}
+	}
+	if err == nil {
+		err = errors.New("at least one startup attempt is required")
+	}
+	return chromeSession, err
+}
+
+// TestStartupTimeout is the startup timeout used by NewBrowserForServer
+var TestStartupTimeout = time.Second * 10
+
+// NewBrowserForServer starts a new chrome headless Session pointed at path
+// on a test server.  It uses defaults suited to tests: no sandbox, quiet
+// startup and a startup timeout of TestStartupTimeout.  Options passed in
+// are applied after these defaults, so they can override them.
+func NewBrowserForServer(srv *httptest.Server, path string, opts ...Option) (*ChromeSession, error) {
+	testOpts := []Option{
+		WithNoSandbox(),
+		WithQuietStartup(),
+		WithStartupTimeout(TestStartupTimeout),
+	}
+	return NewBrowser(srv.URL+path, append(testOpts, opts...)...)
+}
+
+// NewBrowserMulti starts a chrome headless Session on the first url and
+// opens each of the others in its own tab, so several pages can be
+// watched from one chrome process.  The console stays attached to the
+// first tab and can not switch to another.  The other tabs are listed by
+// Targets when the session is started WithRemoteDebuggingPort, and their
+// windows are kept in window.__tabs on the first page, in the order of
+// urls, so same origin tabs can be reached with expressions such as
+// window.__tabs[0].document.title.  The popup blocker is turned off so the
+// tabs can be opened.
+func NewBrowserMulti(urls []string, opts ...Option) (*ChromeSession, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("at least one url is required")
+	}
+
+	opts = append([]Option{withPopupsAllowed()}, opts...)
+	chromeSession, err := NewBrowser(urls[0], opts...)
+	if err != nil {
+		return chromeSession, err
+	}
+
+	for _, url := range urls[1:] {
+		_, err = chromeSession.Eval(`(function(){window.__tabs=window.__tabs||[];var w=window.open(` + jsString(url) + `,"_blank");` +
+			`if(!w){throw new Error("failed to open a tab for " + ` + jsString(url) + `)}window.__tabs.push(w);return true})()`)
+		if err != nil {
+			chromeSession.ForceClose()
+			return chromeSession, err
+		}
+	}
+	return chromeSession, nil
+}
+
+// Name returns the name of the session set with WithName, or the random
+// name it was given if none was set
+func (cs *ChromeSession) Name() string {
+	return cs.name
+}
+
+// randomName generates a short random name for a session
+func randomName() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// debug prints debug output tagged with the session's name
+func (cs *ChromeSession) debug(s ...interface{}) {
+	debug(append([]interface{}{"[" + cs.name + "]"}, s...)...)
 }
 
 func debug(s ...interface{}) {
@@ -1,9 +1,15 @@
 package headlessChrome
 
 import (
+	"context"
+	"errors"
+	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
+
+	"github.com/integrii/interactive"
 )
 
 // TestMainPageScrape tests a scrape from content on httpbin.org
@@ -34,3 +40,263 @@ func TestMainPageScrape(t *testing.T) {
 		t.Fatal("Didnt find google in the output")
 	}
 }
+
+// TestStartupError tests that startup errors describe the failure and
+// still match their underlying cause
+func TestStartupError(t *testing.T) {
+	err := error(&StartupError{
+		Path:   "/usr/bin/chrome",
+		Args:   []string{"--headless", "--repl"},
+		Output: []string{"first line", "second line"},
+		Err:    ErrStartupTimeout,
+	})
+
+	if !errors.Is(err, ErrStartupTimeout) {
+		t.Fatal("StartupError did not unwrap to ErrStartupTimeout")
+	}
+	var startupErr *StartupError
+	if !errors.As(err, &startupErr) || startupErr.Path != "/usr/bin/chrome" {
+		t.Fatal("could not extract StartupError with errors.As")
+	}
+	for _, want := range []string{"/usr/bin/chrome", ErrStartupTimeout.Error(), "first line\nsecond line"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err.Error(), want)
+		}
+	}
+}
+
+// TestStartupErrorCause tests that the most specific error line is picked
+// out of the startup output
+func TestStartupErrorCause(t *testing.T) {
+	err := &StartupError{
+		Path: "/usr/bin/chrome",
+		Output: []string{
+			"[0101/000000.000000:ERROR:bus.cc(399)] Failed to connect to the bus",
+			"[0101/000000.000000:INFO:CONSOLE(0)] Failed to load: net::ERR_NAME_NOT_RESOLVED",
+		},
+		Err: ErrSessionClosed,
+	}
+	if cause := err.Cause(); !strings.Contains(cause, "net::ERR_NAME_NOT_RESOLVED") {
+		t.Fatalf("Cause() = %q, want the network error", cause)
+	}
+	if !strings.Contains(err.Error(), "chrome reported: [0101/000000.000000:INFO:CONSOLE(0)]") {
+		t.Fatalf("Error() = %q, want it to include the cause", err.Error())
+	}
+
+	err.Output = []string{"DevTools listening on ws://127.0.0.1:9222"}
+	if cause := err.Cause(); cause != "" {
+		t.Fatalf("Cause() = %q, want nothing for normal output", cause)
+	}
+}
+
+// TestReadLine tests reading lines from a session until it closes
+func TestReadLine(t *testing.T) {
+	cs := &ChromeSession{Output: make(chan string, 1)}
+
+	cs.Output <- "hello"
+	line, err := cs.ReadLine(context.Background())
+	if err != nil || line != "hello" {
+		t.Fatalf("ReadLine() = %q, %v, want hello", line, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cs.ReadLine(ctx)
+	if err != context.Canceled {
+		t.Fatalf("ReadLine() with canceled context err = %v, want %v", err, context.Canceled)
+	}
+
+	close(cs.Output)
+	_, err = cs.ReadLine(context.Background())
+	if err != ErrSessionClosed {
+		t.Fatalf("ReadLine() on closed session err = %v, want %v", err, ErrSessionClosed)
+	}
+}
+
+// TestCommandLine tests that the command line is quoted for a shell
+func TestCommandLine(t *testing.T) {
+	cs := &ChromeSession{
+		path: ChromePathMacOS,
+		args: []string{"--headless", "--user-agent=it's me", "https://example.com/?a=1&b=2"},
+	}
+	want := `'/Applications/Google Chrome.app/Contents/MacOS/Google Chrome' --headless '--user-agent=it'\''s me' 'https://example.com/?a=1&b=2'`
+	if got := cs.CommandLine(); got != want {
+		t.Fatalf("CommandLine() = %s, want %s", got, want)
+	}
+}
+
+// TestOutputSanitizer tests that console output is split into startup
+// lines and session output, with prompts removed
+func TestOutputSanitizer(t *testing.T) {
+	raw := make(chan string, 5)
+	cs := &ChromeSession{
+		console:     interactiveConsole{&interactive.Session{Output: raw}},
+		Output:      make(chan string, 5),
+		closeOutput: true,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	raw <- "[WARNING] before the banner"
+	raw <- expectedFirstLine
+	raw <- promptPrefix + " document.title"
+	raw <- `{"result":{"type":"string","value":"Example"}}`
+	close(raw)
+	cs.outputSanitizer()
+
+	select {
+	case <-cs.ready:
+	default:
+		t.Fatal("ready was not closed after the banner")
+	}
+	if lines := cs.startupLines(); len(lines) != 1 || lines[0] != "[WARNING] before the banner" {
+		t.Fatalf("startupLines() = %v", lines)
+	}
+
+	var output []string
+	for line := range cs.Output {
+		output = append(output, line)
+	}
+	if len(output) != 1 || output[0] != `{"result":{"type":"string","value":"Example"}}` {
+		t.Fatalf("Output = %v, want only the result line", output)
+	}
+}
+
+// TestPauseOutput tests that paused output is dropped except for results
+func TestPauseOutput(t *testing.T) {
+	raw := make(chan string, 5)
+	cs := &ChromeSession{
+		console:     interactiveConsole{&interactive.Session{Output: raw}},
+		Output:      make(chan string, 5),
+		closeOutput: true,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	cs.PauseOutput()
+	raw <- expectedFirstLine
+	raw <- "console spam"
+	raw <- `{"result":{"type":"number","value":1,"description":"1"}}`
+	close(raw)
+	cs.outputSanitizer()
+
+	if cs.DroppedLines() != 1 {
+		t.Fatalf("DroppedLines() = %d, want 1", cs.DroppedLines())
+	}
+	if line := <-cs.Output; line != `{"result":{"type":"number","value":1,"description":"1"}}` {
+		t.Fatalf("Output received %q, want the result line", line)
+	}
+}
+
+// TestOverflowPolicy tests that full output channels drop lines instead
+// of blocking under the drop policies
+func TestOverflowPolicy(t *testing.T) {
+	tests := map[OverflowPolicy]string{
+		OverflowDropOldest: "third",
+		OverflowDropNewest: "first",
+	}
+	for policy, want := range tests {
+		cs := &ChromeSession{Output: make(chan string, 1), overflow: policy}
+		cs.sendOutput("first")
+		cs.sendOutput("second")
+		cs.sendOutput("third")
+
+		if got := <-cs.Output; got != want {
+			t.Errorf("policy %d left %q in Output, want %q", policy, got, want)
+		}
+		if cs.DroppedLines() != 2 {
+			t.Errorf("policy %d dropped %d lines, want 2", policy, cs.DroppedLines())
+		}
+	}
+}
+
+// TestWait tests that Wait returns once every line of output has been
+// forwarded
+func TestWait(t *testing.T) {
+	raw := make(chan string, 5)
+	cs := &ChromeSession{
+		console:     interactiveConsole{&interactive.Session{Output: raw}},
+		Output:      make(chan string, 5),
+		closeOutput: true,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go cs.outputSanitizer()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := cs.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Wait() while chrome is running err = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	raw <- expectedFirstLine
+	raw <- "[FATAL] chrome is exiting"
+	close(raw)
+	if err := cs.Wait(context.Background()); err != nil {
+		t.Fatalf("Wait() err = %v", err)
+	}
+
+	var output []string
+	for line := range cs.Output {
+		output = append(output, line)
+	}
+	if len(output) != 1 || output[0] != "[FATAL] chrome is exiting" {
+		t.Fatalf("Output = %v, want the final line", output)
+	}
+}
+
+// TestExitTwice tests that closing a session more than once is safe and
+// only stops chrome once, without panicking
+func TestExitTwice(t *testing.T) {
+	fake := newFakeConsole(nil)
+	cs := newFakeSession(t, fake)
+
+	// closes from several goroutines at once, like a deferred Exit racing
+	// the max lifetime timer, are safe too
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cs.Exit()
+		}()
+	}
+	wg.Wait()
+	cs.Exit()
+	cs.ForceClose()
+
+	if fake.exits != 1 || fake.kills != 0 {
+		t.Fatalf("chrome was exited %d times and killed %d times, want one exit", fake.exits, fake.kills)
+	}
+	if len(fake.inputs) != 1 || fake.inputs[0] != ";quit" {
+		t.Fatalf("inputs = %v, want one quit", fake.inputs)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := cs.Wait(ctx); err != nil {
+		t.Fatalf("Wait() after Exit err = %v", err)
+	}
+	if _, err := cs.Eval("1"); err != ErrSessionClosed {
+		t.Fatalf("Eval() after Exit err = %v, want %v", err, ErrSessionClosed)
+	}
+}
+
+// TestExitWithoutConsole tests that a session whose chrome never started,
+// like the one returned by a failed NewBrowser, can be exited without
+// panicking and still has its temporary profile removed
+func TestExitWithoutConsole(t *testing.T) {
+	dir, err := os.MkdirTemp("", "headlessChrome")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cs := &ChromeSession{tempDir: dir}
+	cs.setState(StateDead)
+	cs.Exit()
+	cs.ForceClose()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("temporary profile %s was not removed: %v", dir, err)
+	}
+
+	// a session that was never set up at all is safe to close too
+	(&ChromeSession{}).ForceClose()
+}
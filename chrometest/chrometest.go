@@ -0,0 +1,95 @@
+// Package chrometest provides assertions for tests that drive a page with
+// headlessChrome.  It is kept apart from headlessChrome so that package
+// does not import testing.
+package chrometest
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/integrii/headlessChrome"
+)
+
+// AssertText reports an error if no element matches the selector, or if
+// the text of the first one, with surrounding whitespace trimmed, is not
+// want
+func AssertText(t testing.TB, cs *headlessChrome.ChromeSession, selector, want string) {
+	t.Helper()
+	raw, err := cs.CallFunction(`function(s){var e=document.querySelector(s);`+
+		`return JSON.stringify(e?{found:true,text:e.textContent}:{found:false})}`, selector)
+	checkText(t, selector, want, raw, err)
+}
+
+// checkText reports the result of AssertText
+func checkText(t testing.TB, selector, want, raw string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("reading text of %s: %v", selector, err)
+		return
+	}
+	result := struct {
+		Found bool   `json:"found"`
+		Text  string `json:"text"`
+	}{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		t.Errorf("reading text of %s: %v", selector, err)
+		return
+	}
+	if !result.Found {
+		t.Errorf("no element matches %s", selector)
+		return
+	}
+	if got := strings.TrimSpace(result.Text); got != want {
+		t.Errorf("text of %s = %q, want %q", selector, got, want)
+	}
+}
+
+// AssertExists reports an error if no element matches the selector
+func AssertExists(t testing.TB, cs *headlessChrome.ChromeSession, selector string) {
+	t.Helper()
+	found, err := cs.CallFunction(`function(s){return document.querySelector(s)!==null}`, selector)
+	checkExists(t, selector, found, err)
+}
+
+// checkExists reports the result of AssertExists
+func checkExists(t testing.TB, selector, found string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("looking for %s: %v", selector, err)
+		return
+	}
+	if found != "true" {
+		t.Errorf("no element matches %s", selector)
+	}
+}
+
+// AssertVisible reports an error if the first element matching the
+// selector is missing or not visible.  An element is visible when it
+// takes up space on the page and neither it nor an ancestor is hidden
+// with display, visibility or opacity.
+func AssertVisible(t testing.TB, cs *headlessChrome.ChromeSession, selector string) {
+	t.Helper()
+	state, err := cs.CallFunction(`function(s){var e=document.querySelector(s);if(!e){return "missing"}`+
+		`var r=e.getBoundingClientRect();if(r.width===0||r.height===0){return "hidden"}`+
+		`for(var n=e;n&&n.nodeType===1;n=n.parentElement){var c=getComputedStyle(n);`+
+		`if(c.display==="none"||c.visibility==="hidden"||c.opacity==="0"){return "hidden"}}return "visible"}`, selector)
+	checkVisible(t, selector, state, err)
+}
+
+// checkVisible reports the result of AssertVisible
+func checkVisible(t testing.TB, selector, state string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Errorf("checking visibility of %s: %v", selector, err)
+		return
+	}
+	switch state {
+	case "visible":
+	case "missing":
+		t.Errorf("no element matches %s", selector)
+	default:
+		t.Errorf("%s is not visible", selector)
+	}
+}
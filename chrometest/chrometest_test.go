@@ -0,0 +1,48 @@
+package chrometest
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// recorder is a testing.TB that records the errors reported to it
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+// TestChecks tests the pass and fail paths of each assertion
+func TestChecks(t *testing.T) {
+	tests := []struct {
+		name  string
+		check func(t testing.TB)
+		want  string // the error reported, or "" if the check passes
+	}{
+		{"text matches", func(t testing.TB) { checkText(t, "h1", "Title", `{"found":true,"text":"  Title\n"}`, nil) }, ""},
+		{"text differs", func(t testing.TB) { checkText(t, "h1", "Title", `{"found":true,"text":"Other"}`, nil) }, `text of h1 = "Other", want "Title"`},
+		{"text missing", func(t testing.TB) { checkText(t, "h1", "Title", `{"found":false}`, nil) }, "no element matches h1"},
+		{"text eval error", func(t testing.TB) { checkText(t, "h1", "Title", "", errors.New("session closed")) }, "reading text of h1: session closed"},
+		{"exists", func(t testing.TB) { checkExists(t, "#results", "true", nil) }, ""},
+		{"does not exist", func(t testing.TB) { checkExists(t, "#results", "false", nil) }, "no element matches #results"},
+		{"visible", func(t testing.TB) { checkVisible(t, "#results", "visible", nil) }, ""},
+		{"hidden", func(t testing.TB) { checkVisible(t, "#results", "hidden", nil) }, "#results is not visible"},
+		{"visible missing", func(t testing.TB) { checkVisible(t, "#results", "missing", nil) }, "no element matches #results"},
+	}
+	for _, tc := range tests {
+		r := &recorder{}
+		tc.check(r)
+		switch {
+		case tc.want == "" && len(r.errors) != 0:
+			t.Errorf("%s: unexpected errors %q", tc.name, r.errors)
+		case tc.want != "" && (len(r.errors) != 1 || r.errors[0] != tc.want):
+			t.Errorf("%s: errors = %q, want %q", tc.name, r.errors, tc.want)
+		}
+	}
+}
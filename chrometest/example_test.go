@@ -0,0 +1,24 @@
+package chrometest_test
+
+import (
+	"testing"
+
+	"github.com/integrii/headlessChrome"
+	"github.com/integrii/headlessChrome/chrometest"
+)
+
+// This example checks a search results page from inside a test function
+func Example() {
+	testSearchResults := func(t *testing.T) {
+		browser, err := headlessChrome.NewBrowser("https://example.com/search?q=chrome")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer browser.Exit()
+
+		chrometest.AssertExists(t, browser, "#results")
+		chrometest.AssertVisible(t, browser, "#results")
+		chrometest.AssertText(t, browser, "h1", "Search Results")
+	}
+	_ = testSearchResults
+}
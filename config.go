@@ -0,0 +1,49 @@
+package headlessChrome
+
+import "time"
+
+// SessionConfig is a read-only snapshot of the settings a session was
+// started with, after all options and package defaults were applied
+type SessionConfig struct {
+	Name             string        // the session's name, see WithName
+	ChromePath       string        // the chrome executable
+	Args             []string      // every argument chrome was started with
+	StartupTimeout   time.Duration // how long chrome had to print its banner
+	PollInterval     time.Duration // how often waiting helpers check the page
+	MaxLifetime      time.Duration // 0 when the session has no max lifetime
+	OutputBufferSize int           // the capacity of the Output channel
+	OwnsOutput       bool          // whether Output is closed when the session ends
+	UserDataDir      string        // the profile directory, if one is set
+	TempProfile      bool          // whether the profile is removed on exit
+	DownloadDir      string        // see WithDownloadDir
+	DebuggingPort    int           // see WithRemoteDebuggingPort
+	CustomProtocol   bool          // whether a REPLProtocol was supplied
+	DeviceScale      float64       // the device scale factor, 0 for chrome's default
+}
+
+// Config returns the settings the session was started with
+func (cs *ChromeSession) Config() SessionConfig {
+	config := cs.config
+	config.Args = append([]string{}, cs.config.Args...)
+	return config
+}
+
+// newSessionConfig snapshots the resolved settings of a session
+func newSessionConfig(cs *ChromeSession, o *options) SessionConfig {
+	return SessionConfig{
+		Name:             cs.name,
+		ChromePath:       cs.path,
+		Args:             append([]string{}, cs.args...),
+		StartupTimeout:   o.startupTimeout,
+		PollInterval:     cs.pollInterval(),
+		MaxLifetime:      o.maxLifetime,
+		OutputBufferSize: cap(cs.Output),
+		OwnsOutput:       cs.closeOutput,
+		UserDataDir:      o.userDataDir,
+		TempProfile:      cs.tempDir != "",
+		DownloadDir:      cs.downloadDir,
+		DebuggingPort:    cs.debuggingPort,
+		CustomProtocol:   cs.replProtocol != nil,
+		DeviceScale:      o.scaleFactor,
+	}
+}
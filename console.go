@@ -0,0 +1,25 @@
+package headlessChrome
+
+import "github.com/integrii/interactive"
+
+// console is the running chrome process a session talks to.  Sessions
+// started by this package use an interactiveConsole, and tests can swap in
+// a scripted fake so the session logic runs without chrome.
+type console interface {
+	Write(s string)       // sends a line of input
+	Exit()                // interrupts the process
+	Close()               // closes the terminal
+	ForceClose()          // kills the process
+	Lines() <-chan string // output, closed when the process ends
+}
+
+// interactiveConsole adapts an interactive.Session to the console
+// interface
+type interactiveConsole struct {
+	*interactive.Session
+}
+
+// Lines implements console
+func (c interactiveConsole) Lines() <-chan string {
+	return c.Output
+}
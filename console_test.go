@@ -0,0 +1,204 @@
+package headlessChrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsole is a scripted stand in for chrome.  Each line written to it
+// is passed to respond, and the lines respond returns are printed as
+// output.
+type fakeConsole struct {
+	lines   chan string
+	respond func(input string) []string
+
+	mu      sync.Mutex
+	inputs  []string
+	exits   int
+	kills   int
+	stuck   bool // output never ends, like a process that can not be reaped
+	endOnce sync.Once
+}
+
+// newFakeConsole makes a fake console that has already printed the
+// console banner
+func newFakeConsole(respond func(input string) []string) *fakeConsole {
+	f := &fakeConsole{lines: make(chan string, 100), respond: respond}
+	f.lines <- expectedFirstLine
+	return f
+}
+
+func (f *fakeConsole) Write(s string) {
+	f.mu.Lock()
+	f.inputs = append(f.inputs, s)
+	f.mu.Unlock()
+	if f.respond != nil {
+		for _, line := range f.respond(s) {
+			f.lines <- line
+		}
+	}
+}
+
+func (f *fakeConsole) Exit() {
+	f.mu.Lock()
+	f.exits++
+	f.mu.Unlock()
+	f.end()
+}
+
+func (f *fakeConsole) Close() {}
+
+func (f *fakeConsole) ForceClose() {
+	f.mu.Lock()
+	f.kills++
+	f.mu.Unlock()
+	f.end()
+}
+
+func (f *fakeConsole) Lines() <-chan string {
+	return f.lines
+}
+
+// end closes the output as if the chrome process exited
+func (f *fakeConsole) end() {
+	if f.stuck {
+		return
+	}
+	f.endOnce.Do(func() { close(f.lines) })
+}
+
+// newFakeSession starts a session on a fake console the same way
+// NewBrowser does on chrome, and waits for it to be ready
+func newFakeSession(t testing.TB, fake *fakeConsole) *ChromeSession {
+	cs := &ChromeSession{
+		console:     fake,
+		name:        "fake",
+		Output:      make(chan string, 100),
+		closeOutput: true,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+		abandon:     make(chan struct{}),
+	}
+	go cs.outputSanitizer()
+
+	select {
+	case <-cs.ready:
+	case <-time.After(time.Second):
+		t.Fatal("fake session did not become ready")
+	}
+	return cs
+}
+
+// TestFakeEval tests evaluating against a scripted console, including the
+// logs printed before a result and a thrown exception
+func TestFakeEval(t *testing.T) {
+	fake := newFakeConsole(func(input string) []string {
+		switch input {
+		case "document.title":
+			return []string{promptPrefix + " document.title", "loading title", `{"result":{"type":"string","value":"Example"}}`}
+		case "missing()":
+			return []string{`{"exceptionDetails":{"text":"Uncaught"},"result":{"type":"object","subtype":"error","description":"ReferenceError: missing is not defined"}}`}
+		}
+		return nil
+	})
+	cs := newFakeSession(t, fake)
+	defer cs.Exit()
+
+	result, logs, err := cs.EvalWithLogs("document.title")
+	if err != nil || result != "Example" {
+		t.Fatalf("EvalWithLogs() = %q, %v, want Example", result, err)
+	}
+	if len(logs) != 1 || logs[0] != "loading title" {
+		t.Fatalf("EvalWithLogs() logs = %v, want the line before the result", logs)
+	}
+
+	var jsErr *JSError
+	_, err = cs.Eval("missing()")
+	if !errors.As(err, &jsErr) || jsErr.Message != "ReferenceError: missing is not defined" {
+		t.Fatalf("Eval() err = %v, want the thrown ReferenceError", err)
+	}
+}
+
+// TestExitTimeout tests that a session whose output never ends is killed
+// and then closed anyway
+func TestExitTimeout(t *testing.T) {
+	defer func(timeout time.Duration) { ExitTimeout = timeout }(ExitTimeout)
+	ExitTimeout = time.Millisecond * 20
+
+	fake := newFakeConsole(nil)
+	fake.stuck = true
+	cs := newFakeSession(t, fake)
+	cs.Exit()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := cs.Wait(ctx); err != nil {
+		t.Fatalf("Wait() err = %v, want the session to be abandoned", err)
+	}
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.kills != 1 {
+		t.Fatalf("chrome was killed %d times, want once after the exit timed out", fake.kills)
+	}
+	if _, ok := <-cs.Output; ok {
+		t.Fatal("Output was not closed")
+	}
+}
+
+// TestWriteThenEval tests that a result printed for Write is not returned
+// by the next Eval, whether or not it was read from Output
+func TestWriteThenEval(t *testing.T) {
+	fake := newFakeConsole(func(input string) []string {
+		var marker string
+		if json.Unmarshal([]byte(input), &marker) == nil && strings.HasPrefix(marker, syncMarker) {
+			return []string{`{"result":{"type":"string","value":` + input + `}}`}
+		}
+		switch input {
+		case `document.title="written"`:
+			return []string{`{"result":{"type":"string","value":"written"}}`}
+		case "1 + 1":
+			return []string{`{"result":{"type":"number","value":2,"description":"2"}}`}
+		}
+		return nil
+	})
+	cs := newFakeSession(t, fake)
+	defer cs.Exit()
+
+	cs.Write(`document.title="written"`)
+	result, err := cs.Eval("1 + 1")
+	if err != nil || result != "2" {
+		t.Fatalf("Eval() after an unread Write = %q, %v, want 2", result, err)
+	}
+
+	cs.Write(`document.title="written"`)
+	if line := <-cs.Output; !strings.Contains(line, "written") {
+		t.Fatalf("Output = %q, want the result of the Write", line)
+	}
+	result, err = cs.Eval("1 + 1")
+	if err != nil || result != "2" {
+		t.Fatalf("Eval() after a read Write = %q, %v, want 2", result, err)
+	}
+}
+
+// TestMultiLineEval tests that an expression spanning several lines is
+// written as one line of input
+func TestMultiLineEval(t *testing.T) {
+	fake := newFakeConsole(func(input string) []string {
+		return []string{`{"result":{"type":"number","value":2,"description":"2"}}`}
+	})
+	cs := newFakeSession(t, fake)
+	defer cs.Exit()
+
+	result, err := cs.Eval("1 +\n1")
+	if err != nil || result != "2" {
+		t.Fatalf("Eval() = %q, %v, want 2", result, err)
+	}
+	if len(fake.inputs) != 1 || fake.inputs[0] != `eval("1 +\n1")` {
+		t.Fatalf("inputs = %q, want the expression wrapped in eval", fake.inputs)
+	}
+}
@@ -0,0 +1,75 @@
+package headlessChrome
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// CrashEvent describes a crash chrome reported in its output
+type CrashEvent struct {
+	Type string    // the kind of crash, such as "renderer" or "gpu"
+	Line string    // the line of output the crash was detected in
+	Time time.Time // when the crash was detected
+}
+
+// crashSignature maps a piece of chrome output to the kind of crash it
+// indicates
+type crashSignature struct {
+	text      string
+	crashType string
+}
+
+// crashSignatures are the known lines chrome prints when it crashes
+var crashSignatures = []crashSignature{
+	{text: "Aw, Snap", crashType: "renderer"},
+	{text: "Renderer process crashed", crashType: "renderer"},
+	{text: "renderer crashed", crashType: "renderer"},
+	{text: "GPU process crashed", crashType: "gpu"},
+	{text: "GPU process exited unexpectedly", crashType: "gpu"},
+	{text: "Out of memory", crashType: "oom"},
+	{text: "Received signal 11", crashType: "segfault"},
+	{text: "SIGSEGV", crashType: "segfault"},
+	{text: "Check failed:", crashType: "fatal"},
+	{text: ":FATAL:", crashType: "fatal"},
+}
+
+// crashBufferSize is how many crash events are held for a reader before
+// new ones are dropped
+const crashBufferSize = 10
+
+// chromeLogLine matches the prefix chrome puts on the errors it logs, such
+// as [1015/120000.123456:ERROR:file.cc(42)] with an optional pid and tid
+// before the timestamp
+var chromeLogLine = regexp.MustCompile(`^\[(\d+:)*\d{4}/\d{6}\.\d+:(ERROR|FATAL):`)
+
+// detectCrash checks a line of output for a known crash signature.  Only
+// errors chrome logs itself and the signal report of its crash handler are
+// checked, so pages that print or return the same words are not mistaken
+// for a crash.
+func detectCrash(line string) (CrashEvent, bool) {
+	if _, ok := parseResult(line); ok {
+		return CrashEvent{}, false
+	}
+	text := strings.TrimSpace(line)
+	if !chromeLogLine.MatchString(text) && !strings.HasPrefix(text, "Received signal ") {
+		return CrashEvent{}, false
+	}
+	for _, sig := range crashSignatures {
+		if strings.Contains(text, sig.text) {
+			return CrashEvent{Type: sig.crashType, Line: line, Time: time.Now()}, true
+		}
+	}
+	return CrashEvent{}, false
+}
+
+// reportCrash sends a crash event to the Crashes channel without ever
+// blocking the output reader.  Events are dropped if nobody is reading.
+func (cs *ChromeSession) reportCrash(event CrashEvent) {
+	cs.debug("WARNING: chrome crash detected:", event.Type, event.Line)
+	select {
+	case cs.Crashes <- event:
+	default:
+		cs.debug("WARNING: crash event dropped because the Crashes channel is full")
+	}
+}
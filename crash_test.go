@@ -0,0 +1,44 @@
+package headlessChrome
+
+import "testing"
+
+// TestDetectCrash tests that known crash output is recognized
+func TestDetectCrash(t *testing.T) {
+	tests := map[string]string{
+		`[1015/120000.123:ERROR:headless_shell.cc(420)] Renderer process crashed`: "renderer",
+		`[1015/120000.123:FATAL:memory.cc(22)] Out of memory. size=262144`:        "oom",
+		`Received signal 11 SEGV_MAPERR 000000000000`:                             "segfault",
+		`[123:456:1015/120000.123456:FATAL:check.cc(7)] Check failed: ok`:         "fatal",
+		`{"result":{"type":"string","value":"hello"}}`:                            "",
+	}
+	for line, want := range tests {
+		event, crashed := detectCrash(line)
+		if want == "" {
+			if crashed {
+				t.Errorf("detectCrash(%q) reported a %s crash", line, event.Type)
+			}
+			continue
+		}
+		if !crashed || event.Type != want {
+			t.Errorf("detectCrash(%q) = %q, %v, want %q", line, event.Type, crashed, want)
+		}
+	}
+}
+
+// TestDetectCrashPageOutput tests that crash words printed or returned by a
+// page, or logged by chrome below the error level, are not taken for a crash
+func TestDetectCrashPageOutput(t *testing.T) {
+	lines := []string{
+		`Renderer process crashed`,
+		`console.log: Out of memory while parsing`,
+		`>>> {"result":{"type":"string","value":"Received signal 11"}}`,
+		`{"result":{"type":"string","value":"[1015/120000.123:FATAL:x.cc(1)] SIGSEGV"}}`,
+		`[1015/120000.123:WARNING:gpu_init.cc(12)] GPU process exited unexpectedly`,
+		`[1015/120000.123:ERROR:socket.cc(80)] connection refused`,
+	}
+	for _, line := range lines {
+		if event, crashed := detectCrash(line); crashed {
+			t.Errorf("detectCrash(%q) reported a %s crash", line, event.Type)
+		}
+	}
+}
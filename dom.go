@@ -0,0 +1,109 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrElementNotFound is returned when no element matches a selector
+var ErrElementNotFound = errors.New("no element matches selector")
+
+// ErrAttributeNotFound is returned when an element does not have the
+// requested attribute
+var ErrAttributeNotFound = errors.New("element does not have attribute")
+
+// TextAll returns the text content of every element matching the
+// selector.  An empty slice is returned when nothing matches.
+func (cs *ChromeSession) TextAll(selector string) ([]string, error) {
+	raw, err := cs.Eval(`JSON.stringify(Array.from(document.querySelectorAll(` + jsString(selector) + `)).map(function(e){return e.textContent}))`)
+	if err != nil {
+		return nil, err
+	}
+
+	texts := []string{}
+	err = json.Unmarshal([]byte(raw), &texts)
+	return texts, err
+}
+
+// Attr returns the value of an attribute on the first element matching
+// the selector.  ErrElementNotFound is returned if nothing matches and
+// ErrAttributeNotFound if the element does not have the attribute.
+func (cs *ChromeSession) Attr(selector, attr string) (string, error) {
+	raw, err := cs.Eval(`(function(){var e=document.querySelector(` + jsString(selector) + `);` +
+		`if(!e){return JSON.stringify({found:false})}` +
+		`return JSON.stringify({found:true,value:e.getAttribute(` + jsString(attr) + `)})})()`)
+	if err != nil {
+		return "", err
+	}
+
+	result := struct {
+		Found bool    `json:"found"`
+		Value *string `json:"value"`
+	}{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return "", err
+	}
+	if !result.Found {
+		return "", fmt.Errorf("%w: %s", ErrElementNotFound, selector)
+	}
+	if result.Value == nil {
+		return "", fmt.Errorf("%w: %s", ErrAttributeNotFound, attr)
+	}
+	return *result.Value, nil
+}
+
+// Click clicks the first element matching the selector, returning
+// ErrElementNotFound if nothing matches
+func (cs *ChromeSession) Click(selector string) error {
+	found, err := cs.EvalBool(`(function(){var e=document.querySelector(` + jsString(selector) + `);if(!e){return false}e.click();return true})()`)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrElementNotFound, selector)
+	}
+	return nil
+}
+
+// Fill sets the value of the first input matching the selector and fires
+// its input and change events, so page scripts see the new value as if it
+// were typed.  ErrElementNotFound is returned if nothing matches.
+func (cs *ChromeSession) Fill(selector, value string) error {
+	found, err := cs.EvalBool(`(function(){var e=document.querySelector(` + jsString(selector) + `);if(!e){return false}` +
+		`e.focus();e.value=` + jsString(value) + `;` +
+		`e.dispatchEvent(new Event("input",{bubbles:true}));e.dispatchEvent(new Event("change",{bubbles:true}));return true})()`)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", ErrElementNotFound, selector)
+	}
+	return nil
+}
+
+// ComputedStyle returns the computed value of a CSS property, such as
+// "display" or "color", for the first element matching the selector.
+// ErrElementNotFound is returned if nothing matches.
+func (cs *ChromeSession) ComputedStyle(selector, property string) (string, error) {
+	raw, err := cs.Eval(`(function(){var e=document.querySelector(` + jsString(selector) + `);` +
+		`if(!e){return JSON.stringify({found:false})}` +
+		`return JSON.stringify({found:true,value:getComputedStyle(e).getPropertyValue(` + jsString(property) + `)})})()`)
+	if err != nil {
+		return "", err
+	}
+
+	result := struct {
+		Found bool   `json:"found"`
+		Value string `json:"value"`
+	}{}
+	err = json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return "", err
+	}
+	if !result.Found {
+		return "", fmt.Errorf("%w: %s", ErrElementNotFound, selector)
+	}
+	return result.Value, nil
+}
@@ -0,0 +1,114 @@
+package headlessChrome
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithDownloadDir saves downloaded files into dir without prompting, using
+// the profile preferences.  Use WaitForDownload to wait for a file to
+// arrive.  Some headless chrome builds refuse all downloads unless they
+// are allowed over the devtools protocol, which the console can not reach.
+func WithDownloadDir(dir string) Option {
+	return func(o *options) {
+		WithPreferences(map[string]interface{}{
+			"download": map[string]interface{}{
+				"default_directory":   dir,
+				"prompt_for_download": false,
+			},
+		})(o)
+		o.downloadDir = dir
+	}
+}
+
+// WithDownloadBehavior sets whether pages may download files.  "deny"
+// refuses every download so an unexpected one can not fill the disk, and
+// "allow" saves them without prompting.  It is set with the profile's
+// download_restrictions preference.  The devtools "allowAndName" behavior,
+// which names files by a generated id, can not be reached from the
+// console and is rejected.
+func WithDownloadBehavior(behavior string) Option {
+	return func(o *options) {
+		// values of chrome's DownloadRestriction setting
+		var restriction int
+		switch behavior {
+		case "allow":
+			restriction = 0
+		case "deny":
+			restriction = 3
+		default:
+			o.setErr(errors.New(`download behavior must be "allow" or "deny", not ` + strconv.Quote(behavior)))
+			return
+		}
+		WithPreferences(map[string]interface{}{
+			"download_restrictions": restriction,
+			"download": map[string]interface{}{
+				"prompt_for_download": false,
+			},
+		})(o)
+	}
+}
+
+// listDownloads returns the finished files in a download directory.
+// Chrome writes downloads in progress with a .crdownload extension, so
+// those are skipped.
+func listDownloads(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasSuffix(entry.Name(), ".crdownload") {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	return files, nil
+}
+
+// markExistingDownloads records the files already in the download
+// directory so WaitForDownload only returns new ones
+func (cs *ChromeSession) markExistingDownloads() {
+	cs.seenDownloads = map[string]bool{}
+	if cs.downloadDir == "" {
+		return
+	}
+	files, _ := listDownloads(cs.downloadDir)
+	for _, file := range files {
+		cs.seenDownloads[file] = true
+	}
+}
+
+// WaitForDownload waits for a download to finish in the directory set with
+// WithDownloadDir and returns the path of the file.  Each finished file is
+// only returned once, and files that were in the directory before the
+// session started are ignored.
+func (cs *ChromeSession) WaitForDownload(timeout time.Duration) (string, error) {
+	if cs.downloadDir == "" {
+		return "", errors.New("no download directory was set with WithDownloadDir")
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		files, err := listDownloads(cs.downloadDir)
+		if err != nil && !os.IsNotExist(err) {
+			return "", err
+		}
+		for _, file := range files {
+			if !cs.seenDownloads[file] {
+				cs.seenDownloads[file] = true
+				return file, nil
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("timed out after %v waiting for a download in %s", timeout, cs.downloadDir)
+		}
+		time.Sleep(cs.pollInterval())
+	}
+}
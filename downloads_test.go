@@ -0,0 +1,44 @@
+package headlessChrome
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestWaitForDownload tests that only new, finished downloads are returned
+func TestWaitForDownload(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "old.pdf"), []byte("old"), 0600)
+
+	cs := &ChromeSession{downloadDir: dir, poll: time.Millisecond * 10}
+	cs.markExistingDownloads()
+
+	partial := filepath.Join(dir, "report.pdf.crdownload")
+	os.WriteFile(partial, []byte("partial"), 0600)
+	_, err := cs.WaitForDownload(time.Millisecond * 50)
+	if err == nil {
+		t.Fatal("WaitForDownload() returned a file that was still downloading")
+	}
+
+	os.Rename(partial, filepath.Join(dir, "report.pdf"))
+	file, err := cs.WaitForDownload(time.Second)
+	if err != nil || file != filepath.Join(dir, "report.pdf") {
+		t.Fatalf("WaitForDownload() = %q, %v, want report.pdf", file, err)
+	}
+}
+
+// TestDownloadBehavior tests that behaviors become the download
+// restriction preference
+func TestDownloadBehavior(t *testing.T) {
+	o := newOptions([]Option{WithDownloadBehavior("deny")})
+	if o.err != nil || o.preferences["download_restrictions"] != 3 || !o.needsUserDataDir {
+		t.Fatalf("preferences = %v, want downloads restricted: %v", o.preferences, o.err)
+	}
+
+	o = newOptions([]Option{WithDownloadBehavior("allowAndName")})
+	if o.err == nil {
+		t.Fatal("expected an error for an unsupported behavior")
+	}
+}
@@ -0,0 +1,40 @@
+package headlessChrome
+
+import (
+	"errors"
+	"strconv"
+)
+
+// SetGeolocation overrides navigator.geolocation on the current page so
+// that it reports the supplied position.  Accuracy is in meters.  The
+// chrome console has no access to the devtools emulation domain, so the
+// override is done in javascript and is lost when the page navigates.
+func (cs *ChromeSession) SetGeolocation(lat, lng, accuracy float64) error {
+	if lat < -90 || lat > 90 {
+		return errors.New("latitude must be between -90 and 90")
+	}
+	if lng < -180 || lng > 180 {
+		return errors.New("longitude must be between -180 and 180")
+	}
+	if accuracy < 0 {
+		return errors.New("accuracy can not be negative")
+	}
+
+	position := `{coords:{latitude:` + formatFloat(lat) + `,longitude:` + formatFloat(lng) + `,accuracy:` + formatFloat(accuracy) +
+		`,altitude:null,altitudeAccuracy:null,heading:null,speed:null},timestamp:Date.now()}`
+	_, err := cs.Eval(`(function(){var g=navigator.geolocation;if(!g.__original){g.__original={getCurrentPosition:g.getCurrentPosition,watchPosition:g.watchPosition}}` +
+		`g.getCurrentPosition=function(s){s(` + position + `)};g.watchPosition=function(s){s(` + position + `);return 0};return true})()`)
+	return err
+}
+
+// ClearGeolocation removes an override set by SetGeolocation from the
+// current page
+func (cs *ChromeSession) ClearGeolocation() error {
+	_, err := cs.Eval(`(function(){var g=navigator.geolocation;if(g.__original){g.getCurrentPosition=g.__original.getCurrentPosition;g.watchPosition=g.__original.watchPosition;delete g.__original}return true})()`)
+	return err
+}
+
+// formatFloat formats a float for use in javascript
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
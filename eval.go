@@ -0,0 +1,664 @@
+package headlessChrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// EvalTimeout is how long Eval and its variants wait for the chrome
+// console to print the result of an expression
+var EvalTimeout = time.Second * 30
+
+// ErrEvalTimeout is returned when chrome does not print the result of an
+// expression before EvalTimeout expires
+var ErrEvalTimeout = errors.New("timed out waiting for chrome to return an expression result")
+
+// replResult is the JSON object the chrome console prints after
+// evaluating an expression
+type replResult struct {
+	Result           *remoteObject     `json:"result"`
+	ExceptionDetails *exceptionDetails `json:"exceptionDetails"`
+}
+
+// remoteObject is the value an expression evaluated to
+type remoteObject struct {
+	Type        string          `json:"type"`
+	Subtype     string          `json:"subtype"`
+	ClassName   string          `json:"className"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description"`
+}
+
+// exceptionDetails describes an exception thrown by an expression
+type exceptionDetails struct {
+	Text       string        `json:"text"`
+	Exception  *remoteObject `json:"exception"`
+	StackTrace *stackTrace   `json:"stackTrace"`
+}
+
+// stackTrace is the call stack at the point an exception was thrown
+type stackTrace struct {
+	CallFrames []struct {
+		FunctionName string `json:"functionName"`
+		URL          string `json:"url"`
+		LineNumber   int    `json:"lineNumber"`
+		ColumnNumber int    `json:"columnNumber"`
+	} `json:"callFrames"`
+}
+
+// JSError is returned when an evaluated expression throws.  Error returns
+// only the message; the stack trace, if chrome reported one, is in Stack
+// with one frame per line.
+type JSError struct {
+	Message string
+	Stack   string
+}
+
+// Error implements error
+func (e *JSError) Error() string {
+	return e.Message
+}
+
+// parseResult attempts to parse a line of console output as the
+// result of an evaluated expression
+func parseResult(line string) (*replResult, bool) {
+	line = strings.TrimSpace(line)
+	line = strings.TrimSpace(strings.TrimPrefix(line, promptPrefix))
+	if !strings.HasPrefix(line, "{") {
+		return nil, false
+	}
+
+	r := &replResult{}
+	err := json.Unmarshal([]byte(line), r)
+	if err != nil || r.Result == nil {
+		return nil, false
+	}
+	return r, true
+}
+
+// value returns the result as a string.  Strings are returned without
+// quotes, other values as their JSON or console description.
+func (r *replResult) value() string {
+	switch r.Result.Type {
+	case "undefined":
+		return "undefined"
+	case "string":
+		var s string
+		if err := json.Unmarshal(r.Result.Value, &s); err == nil {
+			return s
+		}
+	}
+	if len(r.Result.Value) > 0 {
+		return string(r.Result.Value)
+	}
+	return r.Result.Description
+}
+
+// err returns the exception thrown by the expression, if there was one
+func (r *replResult) err() error {
+	if r.ExceptionDetails == nil {
+		return nil
+	}
+
+	description := r.ExceptionDetails.Text
+	if r.ExceptionDetails.Exception != nil && r.ExceptionDetails.Exception.Description != "" {
+		description = r.ExceptionDetails.Exception.Description
+	} else if r.Result.Description != "" {
+		description = r.Result.Description
+	}
+
+	// errors describe themselves as their message followed by the stack
+	lines := strings.Split(description, "\n")
+	jsErr := &JSError{Message: lines[0]}
+	frames := []string{}
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			frames = append(frames, line)
+		}
+	}
+	if len(frames) == 0 && r.ExceptionDetails.StackTrace != nil {
+		for _, frame := range r.ExceptionDetails.StackTrace.CallFrames {
+			name := frame.FunctionName
+			if name == "" {
+				name = "<anonymous>"
+			}
+			// chrome numbers lines and columns from zero
+			frames = append(frames, fmt.Sprintf("at %s (%s:%d:%d)", name, frame.URL, frame.LineNumber+1, frame.ColumnNumber+1))
+		}
+	}
+	jsErr.Stack = strings.Join(frames, "\n")
+	return jsErr
+}
+
+// Eval writes a javascript expression to the chrome console and waits for
+// its result.  An exception thrown by the expression is returned as an
+// error.  Expressions spanning several lines are run through eval, see
+// singleLine.  Eval consumes the Output channel while it waits,
+// so nothing else should read from Output at the same time.
+func (cs *ChromeSession) Eval(expr string) (string, error) {
+	result, _, err := cs.EvalWithLogs(expr)
+	return result, err
+}
+
+// Exec evaluates an expression for its side effects, such as changing the
+// DOM, and only returns the exception it throws, if any.  The result is
+// discarded in the page with void, so large values are never serialized
+// and sent back.
+func (cs *ChromeSession) Exec(expr string) error {
+	_, err := cs.Eval(`void (` + expr + `)`)
+	return err
+}
+
+// EvalIsolated evaluates a script in its own function scope so that its
+// let, const and var declarations do not leak into the page's global
+// scope, and running it again does not fail with "Identifier has already
+// been declared".  The script may contain several statements.  The value
+// of the last one is returned.  It is run with eval(), so pages whose
+// Content-Security-Policy blocks eval will reject it.
+func (cs *ChromeSession) EvalIsolated(script string) (string, error) {
+	return cs.Eval(`(function(){return eval(` + jsString(script) + `)})()`)
+}
+
+// jsonReplacer is a JSON.stringify replacer that keeps values the plain
+// serializer rejects or drops.  Repeated references to an object being
+// serialized become "[Circular]", BigInts become strings and Maps and Sets
+// become objects and arrays.
+const jsonReplacer = `(function(){var seen=[];return function(k,v){` +
+	`if(typeof v==="bigint"){return v.toString()}` +
+	`if(v instanceof Map){return Object.fromEntries(v)}` +
+	`if(v instanceof Set){return Array.from(v)}` +
+	`if(typeof v==="object"&&v!==null){while(seen.length&&seen[seen.length-1]!==this){seen.pop()}` +
+	`if(seen.indexOf(v)!==-1){return "[Circular]"}seen.push(v)}` +
+	`return v}})()`
+
+// EvalJSON evaluates an expression and returns its value serialized as
+// JSON in the page.  Eval returns objects as the console's short
+// description, which hides nested fields, while EvalJSON returns the whole
+// structure.  Functions and undefined values are left out as usual for
+// JSON.  The result is printed as a single console line, so very large
+// values are slow and use memory in both chrome and Go.
+func (cs *ChromeSession) EvalJSON(expr string) (string, error) {
+	return cs.Eval(`JSON.stringify((` + expr + `),` + jsonReplacer + `)`)
+}
+
+// CallFunction calls a javascript function with Go values as its
+// arguments and returns the result like Eval.  fn is a function
+// expression, such as "function(sel, text) { ... }" or "(a, b) => a + b".
+// The arguments are encoded with encoding/json, so strings and structs
+// reach the page intact without hand escaping them into the script.
+func (cs *ChromeSession) CallFunction(fn string, args ...interface{}) (string, error) {
+	if args == nil {
+		args = []interface{}{}
+	}
+	encoded, err := json.Marshal(args)
+	if err != nil {
+		return "", err
+	}
+	return cs.Eval(`(` + fn + `).apply(null, ` + string(encoded) + `)`)
+}
+
+// EvalWithLogs works like Eval, but also returns every other line chrome
+// printed between the expression being written and its result arriving,
+// such as messages from console.log.
+func (cs *ChromeSession) EvalWithLogs(expr string) (string, []string, error) {
+	r, logs, err := cs.evalResult(expr)
+	if err != nil {
+		return "", logs, err
+	}
+	return r.Value, logs, r.Err
+}
+
+// EvalContext works like Eval, but gives up waiting and returns the
+// context's error if it is canceled before the result arrives.  The
+// console can not interrupt an expression that is already running, so it
+// keeps running in the page.  Its result is discarded when it arrives,
+// which keeps the session usable for the next evaluation.
+func (cs *ChromeSession) EvalContext(ctx context.Context, expr string) (string, error) {
+	r, _, err := cs.evalResultContext(ctx, expr)
+	if err != nil {
+		return "", err
+	}
+	return r.Value, r.Err
+}
+
+// evalResult writes an expression to the console and waits up to
+// EvalTimeout for the result chrome prints for it.  Other lines read while
+// waiting are returned as logs.
+func (cs *ChromeSession) evalResult(expr string) (*REPLResult, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), EvalTimeout)
+	defer cancel()
+
+	r, logs, err := cs.evalResultContext(ctx, expr)
+	if err == context.DeadlineExceeded {
+		err = ErrEvalTimeout
+	}
+	return r, logs, err
+}
+
+// evalResultContext writes an expression to the console and waits for the
+// result chrome prints for it until the context is done
+func (cs *ChromeSession) evalResultContext(ctx context.Context, expr string) (result *REPLResult, logs []string, err error) {
+	cs.evalLock.Lock()
+	defer cs.evalLock.Unlock()
+	cs.transitionState(StateReady, StateEvaluating)
+	defer cs.transitionState(StateEvaluating, StateReady)
+	start := time.Now()
+	defer func() {
+		cs.traceEval(start, expr, result, logs, err)
+	}()
+
+	if err := cs.Err(); err != nil {
+		return nil, nil, err
+	}
+	if err := cs.sync(ctx); err != nil {
+		return nil, nil, err
+	}
+	input := singleLine(expr)
+	cs.write(input)
+
+	var echoed bool
+	for {
+		line, err := cs.ReadLine(ctx)
+		if err != nil {
+			if err == ctx.Err() {
+				// the result will still be printed later, so skip it then
+				cs.staleResults++
+			}
+			return nil, logs, err
+		}
+		if !echoed && cs.isEcho(line, input) {
+			echoed = true
+			continue
+		}
+		r, ok := cs.protocol().ParseResult(line)
+		if !ok {
+			logs = append(logs, line)
+			continue
+		}
+		if cs.staleResults > 0 {
+			cs.staleResults--
+			continue
+		}
+		return r, logs, nil
+	}
+}
+
+// syncMarker starts the string a session evaluates to find its place in
+// the console output
+const syncMarker = "__headlessChromeSync:"
+
+// sync skips any output left by calls to Write, so the next result read is
+// the one for the expression about to be written.  The console answers in
+// order, so a unique marker is evaluated and everything up to and
+// including its result is discarded.  It must be called with evalLock
+// held.
+func (cs *ChromeSession) sync(ctx context.Context) error {
+	if !cs.unsynced {
+		return nil
+	}
+	cs.syncCount++
+	marker := syncMarker + strconv.Itoa(cs.syncCount)
+	cs.write(jsString(marker))
+	for {
+		line, err := cs.ReadLine(ctx)
+		if err != nil {
+			return err
+		}
+		r, ok := cs.protocol().ParseResult(line)
+		if ok && r.Value == marker {
+			cs.unsynced = false
+			cs.staleResults = 0 // the marker result came after them
+			return nil
+		}
+		cs.debug("skipped unread output before eval:", line)
+	}
+}
+
+// singleLine returns an expression as one line of console input.  The
+// console evaluates each line separately and prints a result for each, so
+// an expression spanning several lines, such as a function passed to
+// CallFunction, is run through eval instead.  Pages whose
+// Content-Security-Policy blocks eval reject those expressions.
+func singleLine(expr string) string {
+	if !strings.ContainsAny(expr, "\r\n") {
+		return expr
+	}
+	return `eval(` + jsString(expr) + `)`
+}
+
+// isEcho reports whether a line is the console repeating an expression
+// that should be dropped under the session's EchoMode
+func (cs *ChromeSession) isEcho(line, expr string) bool {
+	return cs.echo == EchoStrip && strings.TrimSpace(line) == strings.TrimSpace(expr)
+}
+
+// EvalCollect evaluates an expression and collects the lines of output
+// it prints, such as console.log calls from a scraping script, until
+// until returns true for a line.  That sentinel line is not included.  It
+// stops early with an error if the expression throws, the session closes
+// or EvalTimeout passes, returning the lines collected so far.
+func (cs *ChromeSession) EvalCollect(expr string, until func(string) bool) ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), EvalTimeout)
+	defer cancel()
+
+	var lines []string
+	err := cs.evalOutput(ctx, expr, func(line string) bool {
+		if until(line) {
+			return false
+		}
+		lines = append(lines, line)
+		return true
+	})
+	if err == context.DeadlineExceeded {
+		err = ErrEvalTimeout
+	}
+	return lines, err
+}
+
+// EvalStream evaluates an expression and passes each line of output it
+// prints to onLine as it arrives, until onLine returns false.  It is the
+// streaming form of EvalCollect for scripts that print more lines than
+// should be held at once.  Output is not read while onLine runs, so with
+// a slow callback chrome's output waits in the Output channel.
+// It stops early with an error if the expression throws, the session
+// closes or EvalTimeout passes.
+func (cs *ChromeSession) EvalStream(expr string, onLine func(string) bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), EvalTimeout)
+	defer cancel()
+
+	err := cs.evalOutput(ctx, expr, onLine)
+	if err == context.DeadlineExceeded {
+		err = ErrEvalTimeout
+	}
+	return err
+}
+
+// evalOutput writes an expression to the console and passes each line of
+// output other than its result to onLine until onLine returns false.  It
+// stops with an error if the expression throws, the context is done or
+// the session closes.
+func (cs *ChromeSession) evalOutput(ctx context.Context, expr string, onLine func(string) bool) (err error) {
+	cs.evalLock.Lock()
+	defer cs.evalLock.Unlock()
+	cs.transitionState(StateReady, StateEvaluating)
+	defer cs.transitionState(StateEvaluating, StateReady)
+	start := time.Now()
+	defer func() {
+		cs.traceEval(start, expr, nil, nil, err)
+	}()
+
+	if err := cs.Err(); err != nil {
+		return err
+	}
+	if err := cs.sync(ctx); err != nil {
+		return err
+	}
+	input := singleLine(expr)
+	cs.write(input)
+
+	var gotResult, echoed bool
+	for {
+		line, err := cs.ReadLine(ctx)
+		if err != nil {
+			if err == ctx.Err() && !gotResult {
+				cs.staleResults++
+			}
+			return err
+		}
+		if !echoed && cs.isEcho(line, input) {
+			echoed = true
+			continue
+		}
+		r, ok := cs.protocol().ParseResult(line)
+		if ok {
+			if cs.staleResults > 0 {
+				cs.staleResults--
+				continue
+			}
+			gotResult = true
+			if r.Err != nil {
+				return r.Err
+			}
+			continue
+		}
+		if !onLine(line) {
+			if !gotResult {
+				cs.staleResults++
+			}
+			return nil
+		}
+	}
+}
+
+// EvalBool evaluates an expression that returns a boolean.  Only a strict
+// true or false is accepted.  Truthy values such as 1 or "yes" return an
+// error, so wrap them in Boolean() if that is what you want.
+func (cs *ChromeSession) EvalBool(expr string) (bool, error) {
+	r, _, err := cs.evalResult(expr)
+	if err != nil {
+		return false, err
+	}
+	if r.Err != nil {
+		return false, r.Err
+	}
+	if r.Type != "boolean" {
+		return false, fmt.Errorf("expression returned %s %s, not a boolean", r.Type, r.Value)
+	}
+	return r.Value == "true", nil
+}
+
+// EvalNumber evaluates an expression that returns a number, such as the
+// length of a list of elements.  Undefined, NaN and non-numeric results
+// return an error.
+func (cs *ChromeSession) EvalNumber(expr string) (float64, error) {
+	r, _, err := cs.evalResult(expr)
+	if err != nil {
+		return 0, err
+	}
+	if r.Err != nil {
+		return 0, r.Err
+	}
+	if r.Type != "number" {
+		return 0, fmt.Errorf("expression returned %s %s, not a number", r.Type, r.Value)
+	}
+	if r.Value == "NaN" {
+		return 0, errors.New("expression returned NaN")
+	}
+	return strconv.ParseFloat(r.Value, 64)
+}
+
+// EvalNumberLocale evaluates an expression that returns a number formatted
+// for a locale, such as "1.234,56 €" in "de-DE", and returns the number.
+// The page's Intl support is used to find the locale's grouping and decimal
+// separators, which are normalized before parsing.  Other characters like
+// currency symbols are ignored.  An expression that already returns a
+// javascript number is returned as is.
+func (cs *ChromeSession) EvalNumberLocale(expr, locale string) (float64, error) {
+	raw, err := cs.Eval(`(function(){var v=(` + expr + `),g="",d=".";` +
+		`new Intl.NumberFormat(` + jsString(locale) + `).formatToParts(12345.6).forEach(function(p){` +
+		`if(p.type==="group"){g=p.value}if(p.type==="decimal"){d=p.value}});` +
+		`return JSON.stringify({number:typeof v==="number",value:String(v),group:g,decimal:d})})()`)
+	if err != nil {
+		return 0, err
+	}
+	return parseLocaleNumber(raw)
+}
+
+// parseLocaleNumber parses the value and separators reported by
+// EvalNumberLocale
+func parseLocaleNumber(raw string) (float64, error) {
+	result := struct {
+		Number  bool   `json:"number"`
+		Value   string `json:"value"`
+		Group   string `json:"group"`
+		Decimal string `json:"decimal"`
+	}{}
+	err := json.Unmarshal([]byte(raw), &result)
+	if err != nil {
+		return 0, err
+	}
+
+	s := result.Value
+	if !result.Number {
+		if result.Group != "" {
+			s = strings.ReplaceAll(s, result.Group, "")
+		}
+		if result.Decimal != "" {
+			s = strings.ReplaceAll(s, result.Decimal, ".")
+		}
+		// many locales write negative numbers with U+2212 MINUS SIGN
+		s = strings.ReplaceAll(s, "\u2212", "-")
+		s = strings.Map(func(r rune) rune {
+			if r >= '0' && r <= '9' || r == '.' || r == '-' {
+				return r
+			}
+			return -1
+		}, s)
+	}
+	if s == "NaN" {
+		return 0, errors.New("expression returned NaN")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse %q as a number: %w", result.Value, err)
+	}
+	return f, nil
+}
+
+// EvalRetry evaluates an expression up to attempts times, waiting interval
+// between tries, until it neither throws nor returns an empty result
+// ("", undefined or null).  This suits expressions that depend on the page
+// finishing some work, like an element that has not rendered yet.  The
+// last error, or an error describing the empty result, is returned if no
+// attempt succeeds.
+func (cs *ChromeSession) EvalRetry(expr string, attempts int, interval time.Duration) (string, error) {
+	err := errors.New("at least one attempt is required")
+	for attempt := 1; attempt <= attempts; attempt++ {
+		var result string
+		result, err = cs.Eval(expr)
+		if err == ErrSessionClosed {
+			return "", err
+		}
+		if err == nil {
+			if result != "" && result != "undefined" && result != "null" {
+				return result, nil
+			}
+			err = fmt.Errorf("expression returned an empty result: %q", result)
+		}
+		cs.debug("eval attempt", attempt, "of", attempts, "failed:", err)
+		if attempt < attempts {
+			time.Sleep(interval)
+		}
+	}
+	return "", err
+}
+
+// EvalAfterReady waits up to timeout for the page's document to finish
+// parsing, so that selectors can find its elements, and then evaluates
+// the expression.  Subresources such as images may still be loading.
+func (cs *ChromeSession) EvalAfterReady(expr string, timeout time.Duration) (string, error) {
+	err := cs.waitForCondition(`document.readyState!=="loading"`, cs.pollInterval(), timeout)
+	if err != nil {
+		return "", err
+	}
+	return cs.Eval(expr)
+}
+
+// PollInterval is how often helpers that wait on the page check it again,
+// unless a session is started WithPollInterval
+var PollInterval = time.Millisecond * 100
+
+// asyncCounter makes the key each EvalAsync result is stored under unique
+var asyncCounter uint64
+
+// asyncResult is the settled state of a promise started by EvalAsync
+type asyncResult struct {
+	OK    bool   `json:"ok"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// EvalAsync evaluates an expression that returns a promise, or that uses
+// await, and waits up to timeout for the promise to settle.  The resolved
+// value is returned as a string (non-string values as JSON) and a rejected
+// promise is returned as an error.  The promise settles in the page while
+// this polls for its result, so other Evals can safely run concurrently.
+func (cs *ChromeSession) EvalAsync(expr string, timeout time.Duration) (string, error) {
+	key := "p" + strconv.FormatUint(atomic.AddUint64(&asyncCounter, 1), 10) + "_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	store := `window.__asyncResults["` + key + `"]`
+
+	// start the promise and store its settled value on the window
+	_, err := cs.Eval(`(function(){window.__asyncResults=window.__asyncResults||{};` +
+		`(async function(){return (` + expr + `)})().then(` +
+		`function(v){` + store + `={ok:true,value:typeof v==="string"?v:(v===undefined?"undefined":JSON.stringify(v))}},` +
+		`function(e){` + store + `={ok:false,error:String(e&&e.stack||e)}});return true})()`)
+	if err != nil {
+		return "", err
+	}
+
+	// poll for the result until it arrives or we time out
+	deadline := time.Now().Add(timeout)
+	for {
+		raw, err := cs.Eval(`(function(){var r=window.__asyncResults&&` + store + `;if(!r){return ""}delete ` + store + `;return JSON.stringify(r)})()`)
+		if err != nil {
+			return "", err
+		}
+		if raw != "" {
+			result := asyncResult{}
+			err = json.Unmarshal([]byte(raw), &result)
+			if err != nil {
+				return "", err
+			}
+			if !result.OK {
+				return "", errors.New("promise rejected: " + result.Error)
+			}
+			return result.Value, nil
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("%w: promise did not settle within %v", ErrEvalTimeout, timeout)
+		}
+		time.Sleep(cs.pollInterval())
+	}
+}
+
+// jsString quotes a Go string as a javascript string literal so it can be
+// safely placed into an expression
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// EvalInFrame evaluates an expression inside the iframe matched by
+// frameSelector, so globals like document and window refer to the frame.
+// Only same-origin frames can be reached this way.  Evaluating in a
+// cross-origin frame is blocked by the browser and returns its
+// SecurityError.
+func (cs *ChromeSession) EvalInFrame(frameSelector, expr string) (string, error) {
+	return cs.Eval(`(function(){var f=document.querySelector(` + jsString(frameSelector) + `);` +
+		`if(!f){throw new Error("no frame matches selector " + ` + jsString(frameSelector) + `)}` +
+		`if(!f.contentWindow){throw new Error("element is not a frame")}` +
+		`return f.contentWindow.eval(` + jsString(expr) + `)})()`)
+}
+
+// pageTimeoutGrace is how much longer than the in-page timeout
+// EvalAsyncPageTimeout waits for the page to report back
+const pageTimeoutGrace = time.Second * 5
+
+// EvalAsyncPageTimeout works like EvalAsync, but the timeout is enforced
+// inside the page by racing the promise against a timer.  A slow promise
+// then rejects in the page and returns a clean error, instead of being
+// abandoned while it keeps running.  This only works for expressions that
+// return a promise or use await.  A synchronous expression that never
+// returns blocks the page and can not be timed out this way.
+func (cs *ChromeSession) EvalAsyncPageTimeout(expr string, timeout time.Duration) (string, error) {
+	ms := strconv.FormatInt(int64(timeout/time.Millisecond), 10)
+	return cs.EvalAsync(`Promise.race([(async function(){return (`+expr+`)})(),`+
+		`new Promise(function(resolve,reject){setTimeout(function(){reject(new Error("timed out in page after `+ms+`ms"))},`+ms+`)})])`,
+		timeout+pageTimeoutGrace)
+}
@@ -0,0 +1,145 @@
+package headlessChrome
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestParseResult tests parsing of the results printed by the chrome console
+func TestParseResult(t *testing.T) {
+	tests := []struct {
+		line    string
+		isValue bool
+		value   string
+		err     string
+	}{
+		{line: `{"result":{"type":"string","value":"hello"}}`, isValue: true, value: "hello"},
+		{line: `>>> {"result":{"description":"2","type":"number","value":2}}`, isValue: true, value: "2"},
+		{line: `{"result":{"type":"boolean","value":true}}`, isValue: true, value: "true"},
+		{line: `{"result":{"type":"undefined"}}`, isValue: true, value: "undefined"},
+		{line: `{"result":{"className":"HTMLDivElement","description":"div#main","type":"object","subtype":"node"}}`, isValue: true, value: "div#main"},
+		{line: `{"exceptionDetails":{"text":"Uncaught","exception":{"description":"ReferenceError: x is not defined"}},"result":{"type":"object","subtype":"error","description":"ReferenceError: x is not defined"}}`, isValue: true, err: "ReferenceError: x is not defined"},
+		{line: `>>> document.title`},
+		{line: `hello from console.log`},
+		{line: `{"notAResult":true}`},
+	}
+
+	for _, tc := range tests {
+		r, ok := parseResult(tc.line)
+		if ok != tc.isValue {
+			t.Errorf("parseResult(%q) ok = %v, want %v", tc.line, ok, tc.isValue)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if tc.err != "" {
+			if r.err() == nil || r.err().Error() != tc.err {
+				t.Errorf("parseResult(%q) err = %v, want %q", tc.line, r.err(), tc.err)
+			}
+			continue
+		}
+		if r.err() != nil {
+			t.Errorf("parseResult(%q) unexpected err: %v", tc.line, r.err())
+		}
+		if r.value() != tc.value {
+			t.Errorf("parseResult(%q) value = %q, want %q", tc.line, r.value(), tc.value)
+		}
+	}
+}
+
+// TestJSString tests that Go strings are quoted into safe javascript literals
+func TestJSString(t *testing.T) {
+	tests := map[string]string{
+		`plain`:           `"plain"`,
+		`say "hi"`:        `"say \"hi\""`,
+		"line\nbreak":     `"line\nbreak"`,
+		`</script>`:       `"\u003c/script\u003e"`,
+		"sep\u2028arator": `"sep\u2028arator"`,
+	}
+	for in, want := range tests {
+		if got := jsString(in); got != want {
+			t.Errorf("jsString(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+// TestNumberResults tests the values returned for numeric results
+func TestNumberResults(t *testing.T) {
+	tests := map[string]string{
+		`{"result":{"type":"number","value":3,"description":"3"}}`:                                 "3",
+		`{"result":{"type":"number","value":1.5,"description":"1.5"}}`:                             "1.5",
+		`{"result":{"type":"number","unserializableValue":"NaN","description":"NaN"}}`:             "NaN",
+		`{"result":{"type":"number","unserializableValue":"-Infinity","description":"-Infinity"}}`: "-Infinity",
+	}
+	for line, want := range tests {
+		r, ok := parseResult(line)
+		if !ok {
+			t.Fatalf("parseResult(%q) failed", line)
+		}
+		if r.value() != want {
+			t.Errorf("parseResult(%q) value = %q, want %q", line, r.value(), want)
+		}
+	}
+}
+
+// TestJSError tests that the stack of a thrown error is split from its
+// message
+func TestJSError(t *testing.T) {
+	r, ok := parseResult(`{"exceptionDetails":{"text":"Uncaught","exception":{"description":"Error: boom\n    at f (<anonymous>:1:30)\n    at <anonymous>:1:40"}},"result":{"type":"object","subtype":"error"}}`)
+	if !ok {
+		t.Fatal("failed to parse exception result")
+	}
+	var jsErr *JSError
+	if !errors.As(r.err(), &jsErr) {
+		t.Fatalf("err() = %T, want *JSError", r.err())
+	}
+	if jsErr.Message != "Error: boom" || jsErr.Stack != "at f (<anonymous>:1:30)\nat <anonymous>:1:40" {
+		t.Fatalf("err() = %+v, want message and stack split", jsErr)
+	}
+
+	r, _ = parseResult(`{"exceptionDetails":{"text":"Uncaught","exception":{"type":"string","value":"boom"},"stackTrace":{"callFrames":[{"functionName":"","url":"https://example.com/app.js","lineNumber":9,"columnNumber":4}]}},"result":{"type":"string","value":"boom"}}`)
+	if !errors.As(r.err(), &jsErr) || jsErr.Message != "Uncaught" || jsErr.Stack != "at <anonymous> (https://example.com/app.js:10:5)" {
+		t.Fatalf("err() = %+v, want stack from call frames", r.err())
+	}
+}
+
+// TestIsEcho tests that echoes are only matched when stripping is enabled
+func TestIsEcho(t *testing.T) {
+	cs := &ChromeSession{}
+	if cs.isEcho("document.title", "document.title") {
+		t.Fatal("echo matched with EchoKeep")
+	}
+
+	cs.echo = EchoStrip
+	if !cs.isEcho(" document.title\r", "document.title") {
+		t.Fatal("echo was not matched with EchoStrip")
+	}
+	if cs.isEcho("Example Domain", "document.title") {
+		t.Fatal("output that is not an echo was matched")
+	}
+}
+
+// TestParseLocaleNumber tests normalizing locale formatted numbers
+func TestParseLocaleNumber(t *testing.T) {
+	tests := map[string]float64{
+		// a javascript number is not mistaken for a grouped string
+		`{"number":true,"value":"1234.5","group":".","decimal":","}`:      1234.5,
+		`{"number":false,"value":"1.234,56 €","group":".","decimal":","}`: 1234.56,
+		// sv-SE groups with a no-break space and uses U+2212 for minus
+		"{\"number\":false,\"value\":\"\u22121\u00a0234,5\",\"group\":\"\u00a0\",\"decimal\":\",\"}": -1234.5,
+		`{"number":false,"value":"-$1,234.50","group":",","decimal":"."}`:                            -1234.5,
+	}
+	for raw, want := range tests {
+		got, err := parseLocaleNumber(raw)
+		if err != nil || got != want {
+			t.Errorf("parseLocaleNumber(%s) = %v, %v, want %v", raw, got, err, want)
+		}
+	}
+
+	for _, raw := range []string{`{"number":true,"value":"NaN"}`, `{"number":false,"value":"n/a","group":",","decimal":"."}`} {
+		if _, err := parseLocaleNumber(raw); err == nil {
+			t.Errorf("parseLocaleNumber(%s) did not return an error", raw)
+		}
+	}
+}
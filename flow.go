@@ -0,0 +1,89 @@
+package headlessChrome
+
+import (
+	"fmt"
+	"time"
+)
+
+// FlowWaitTimeout is how long the WaitForSelector step of a Flow waits
+var FlowWaitTimeout = time.Second * 30
+
+// Flow is a sequence of steps run against a session, built by chaining
+// calls and started with Run.  For example:
+//
+//	err := browser.Flow().
+//		Navigate("https://example.com/search").
+//		WaitForSelector("#q").
+//		Fill("#q", "headless chrome").
+//		Click("button[type=submit]").
+//		Run()
+type Flow struct {
+	cs    *ChromeSession
+	steps []flowStep
+}
+
+// flowStep is a single named step of a Flow
+type flowStep struct {
+	name string
+	run  func() error
+}
+
+// Flow starts a new, empty sequence of steps for the session
+func (cs *ChromeSession) Flow() *Flow {
+	return &Flow{cs: cs}
+}
+
+// add appends a step to the flow
+func (f *Flow) add(name string, run func() error) *Flow {
+	f.steps = append(f.steps, flowStep{name: name, run: run})
+	return f
+}
+
+// Navigate adds a step that navigates to a url and waits for it to load
+func (f *Flow) Navigate(url string) *Flow {
+	return f.add(fmt.Sprintf("navigate to %q", url), func() error {
+		return f.cs.Navigate(url)
+	})
+}
+
+// WaitForSelector adds a step that waits up to FlowWaitTimeout for an
+// element matching the selector to exist
+func (f *Flow) WaitForSelector(selector string) *Flow {
+	return f.add(fmt.Sprintf("wait for %q", selector), func() error {
+		return f.cs.WaitForSelector(selector, FlowWaitTimeout)
+	})
+}
+
+// Click adds a step that clicks the element matching the selector
+func (f *Flow) Click(selector string) *Flow {
+	return f.add(fmt.Sprintf("click %q", selector), func() error {
+		return f.cs.Click(selector)
+	})
+}
+
+// Fill adds a step that sets the value of the input matching the selector
+func (f *Flow) Fill(selector, value string) *Flow {
+	return f.add(fmt.Sprintf("fill %q", selector), func() error {
+		return f.cs.Fill(selector, value)
+	})
+}
+
+// Eval adds a step that evaluates an expression and fails if it throws
+func (f *Flow) Eval(expr string) *Flow {
+	return f.add(fmt.Sprintf("eval %q", expr), func() error {
+		return f.cs.Exec(expr)
+	})
+}
+
+// Run runs the steps in order and stops at the first one that fails.  The
+// error returned names the failed step.
+func (f *Flow) Run() error {
+	for i, step := range f.steps {
+		f.cs.debug("flow step", i+1, "of", len(f.steps), ":", step.name)
+		err := step.run()
+		if err != nil {
+			return fmt.Errorf("flow step %d (%s) failed: %w", i+1, step.name, err)
+		}
+	}
+	return nil
+}
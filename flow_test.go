@@ -0,0 +1,25 @@
+package headlessChrome
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFlowStopsAtFailure tests that a flow stops at the first failed step
+// and names it in the error
+func TestFlowStopsAtFailure(t *testing.T) {
+	var ran []string
+	f := (&ChromeSession{}).Flow()
+	f.add("first", func() error { ran = append(ran, "first"); return nil })
+	f.add("second", func() error { ran = append(ran, "second"); return errors.New("boom") })
+	f.add("third", func() error { ran = append(ran, "third"); return nil })
+
+	err := f.Run()
+	if err == nil || !strings.Contains(err.Error(), "flow step 2 (second)") {
+		t.Fatalf("Run() err = %v, want it to name step 2", err)
+	}
+	if len(ran) != 2 {
+		t.Fatalf("Run() ran %v, want it to stop after the second step", ran)
+	}
+}
@@ -0,0 +1,182 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+)
+
+// HAR is a minimal HTTP Archive of the requests a page made, which can be
+// written out with encoding/json and opened in HAR viewers
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog is the root of a HAR file
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator names the tool that made a HAR file
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one request and its response
+type HAREntry struct {
+	StartedDateTime time.Time   `json:"startedDateTime"`
+	Time            float64     `json:"time"` // total milliseconds
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+}
+
+// HARRequest describes a request.  Headers are not visible to the page, so
+// the list is always empty.  Resource timing does not report the method
+// either, so Method is left empty rather than guessed.
+type HARRequest struct {
+	Method      string      `json:"method"`
+	URL         string      `json:"url"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []HARHeader `json:"headers"`
+	QueryString []HARHeader `json:"queryString"`
+	Cookies     []HARHeader `json:"cookies"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// HARResponse describes a response.  Status is 0 when chrome does not
+// report it, such as for cross origin requests.
+type HARResponse struct {
+	Status      int         `json:"status"`
+	StatusText  string      `json:"statusText"`
+	HTTPVersion string      `json:"httpVersion"`
+	Headers     []HARHeader `json:"headers"`
+	Cookies     []HARHeader `json:"cookies"`
+	Content     HARContent  `json:"content"`
+	RedirectURL string      `json:"redirectURL"`
+	HeadersSize int         `json:"headersSize"`
+	BodySize    int         `json:"bodySize"`
+}
+
+// HARHeader is a name and value pair
+type HARHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARContent describes a response body
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+}
+
+// HARTimings breaks an entry's time into phases, in milliseconds.  An
+// optional phase that did not happen, or that chrome hides from the page,
+// is -1.  Send, Wait and Receive are required by the format and are 0
+// instead.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	SSL     float64 `json:"ssl"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// harScript reads the timing entries for the page and everything it
+// loaded, with start times in milliseconds since the epoch
+const harScript = `(function(){var o=performance.timeOrigin;` +
+	`return JSON.stringify(performance.getEntriesByType("navigation").concat(performance.getEntriesByType("resource")).map(function(e){` +
+	`function d(a,b){return a>0&&b>=a?b-a:-1}` +
+	`return {url:e.name,start:o+e.startTime,duration:e.duration,protocol:e.nextHopProtocol||"",status:e.responseStatus||0,` +
+	`size:e.transferSize||0,bodySize:e.encodedBodySize||0,dns:d(e.domainLookupStart,e.domainLookupEnd),` +
+	`connect:d(e.connectStart,e.connectEnd),ssl:d(e.secureConnectionStart,e.connectEnd),` +
+	`wait:d(e.requestStart,e.responseStart),receive:d(e.responseStart,e.responseEnd)}}))})()`
+
+// CaptureHAR runs navigate, which should load a page, such as by calling
+// Navigate, and then returns a HAR of the requests that page made.  The
+// console can not see devtools network events, so the archive is built
+// from the page's resource timing entries.  That means it has no headers
+// or bodies, only includes requests that finished, leaves cross origin
+// timings and statuses out unless the server sends Timing-Allow-Origin,
+// and stops at chrome's default of 250 resources per page.
+func (cs *ChromeSession) CaptureHAR(navigate func() error) (HAR, error) {
+	err := navigate()
+	if err != nil {
+		return HAR{}, err
+	}
+
+	raw, err := cs.Eval(harScript)
+	if err != nil {
+		return HAR{}, err
+	}
+	return parseHAR(raw)
+}
+
+// parseHAR builds a HAR from the entries reported by harScript
+func parseHAR(raw string) (HAR, error) {
+	var entries []struct {
+		URL      string  `json:"url"`
+		Start    float64 `json:"start"`
+		Duration float64 `json:"duration"`
+		Protocol string  `json:"protocol"`
+		Status   int     `json:"status"`
+		Size     int     `json:"size"`
+		BodySize int     `json:"bodySize"`
+		DNS      float64 `json:"dns"`
+		Connect  float64 `json:"connect"`
+		SSL      float64 `json:"ssl"`
+		Wait     float64 `json:"wait"`
+		Receive  float64 `json:"receive"`
+	}
+	err := json.Unmarshal([]byte(raw), &entries)
+	if err != nil {
+		return HAR{}, err
+	}
+
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "headlessChrome", Version: "1"},
+		Entries: make([]HAREntry, 0, len(entries)),
+	}}
+	for _, e := range entries {
+		har.Log.Entries = append(har.Log.Entries, HAREntry{
+			StartedDateTime: time.Unix(0, int64(e.Start*float64(time.Millisecond))).UTC(),
+			Time:            e.Duration,
+			Request: HARRequest{
+				URL:         e.URL,
+				HTTPVersion: e.Protocol,
+				Headers:     []HARHeader{},
+				QueryString: []HARHeader{},
+				Cookies:     []HARHeader{},
+				HeadersSize: -1,
+				BodySize:    -1,
+			},
+			Response: HARResponse{
+				Status:      e.Status,
+				HTTPVersion: e.Protocol,
+				Headers:     []HARHeader{},
+				Cookies:     []HARHeader{},
+				Content:     HARContent{Size: e.BodySize},
+				HeadersSize: -1,
+				BodySize:    e.BodySize,
+			},
+			Timings: HARTimings{
+				Blocked: -1,
+				DNS:     e.DNS,
+				Connect: e.Connect,
+				SSL:     e.SSL,
+				Send:    0,
+				Wait:    math.Max(e.Wait, 0),
+				Receive: math.Max(e.Receive, 0),
+			},
+		})
+	}
+	return har, nil
+}
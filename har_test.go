@@ -0,0 +1,50 @@
+package headlessChrome
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseHAR tests that timing entries become HAR entries
+func TestParseHAR(t *testing.T) {
+	har, err := parseHAR(`[{"url":"https://example.com/","start":1700000000000,"duration":120.5,"protocol":"h2","status":200,` +
+		`"size":1500,"bodySize":1200,"dns":2,"connect":10,"ssl":6,"wait":80,"receive":5}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 1 {
+		t.Fatalf("parseHAR() = %+v, want one entry", har)
+	}
+
+	entry := har.Log.Entries[0]
+	if !entry.StartedDateTime.Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("StartedDateTime = %v", entry.StartedDateTime)
+	}
+	if entry.Request.URL != "https://example.com/" || entry.Response.Status != 200 || entry.Response.Content.Size != 1200 {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Timings.Wait != 80 || entry.Timings.Blocked != -1 {
+		t.Errorf("Timings = %+v", entry.Timings)
+	}
+
+	if entry.Request.Method != "" {
+		t.Errorf("Method = %q, want it left empty", entry.Request.Method)
+	}
+
+	// phases hidden from the page are reported as -1, but wait and
+	// receive are required and can not be negative
+	har, err = parseHAR(`[{"url":"https://cdn.example.com/a.js","start":1700000000000,"duration":30,` +
+		`"dns":-1,"connect":-1,"ssl":-1,"wait":-1,"receive":-1}]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	timings := har.Log.Entries[0].Timings
+	if timings.Wait != 0 || timings.Receive != 0 || timings.DNS != -1 {
+		t.Errorf("hidden Timings = %+v, want wait and receive of 0", timings)
+	}
+
+	_, err = parseHAR("undefined")
+	if err == nil {
+		t.Fatal("expected an error parsing a non-JSON result")
+	}
+}
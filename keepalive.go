@@ -0,0 +1,59 @@
+package headlessChrome
+
+import "time"
+
+// StartKeepAlive evaluates expr every interval until StopKeepAlive is
+// called or the session closes, which keeps pages that drop idle
+// connections busy.  An empty expr evaluates a no-op.  The pings take
+// turns with other evaluations, so they never interleave with an Eval.
+// Starting a keep-alive replaces any that is already running.  An interval
+// that is not positive is ignored and leaves any running keep-alive as is.
+func (cs *ChromeSession) StartKeepAlive(expr string, interval time.Duration) {
+	if interval <= 0 {
+		cs.debug("WARNING: ignoring keep-alive with an interval of", interval)
+		return
+	}
+	if expr == "" {
+		expr = "void 0"
+	}
+
+	cs.keepAliveLock.Lock()
+	defer cs.keepAliveLock.Unlock()
+	if cs.keepAlive != nil {
+		close(cs.keepAlive)
+	}
+	stop := make(chan struct{})
+	cs.keepAlive = stop
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-cs.done:
+				return
+			case <-ticker.C:
+				err := cs.Exec(expr)
+				if err == ErrSessionClosed {
+					return
+				}
+				if err != nil {
+					cs.debug("WARNING: keep-alive evaluation failed:", err)
+				}
+			}
+		}
+	}()
+}
+
+// StopKeepAlive stops pings started by StartKeepAlive.  It does nothing if
+// none are running.
+func (cs *ChromeSession) StopKeepAlive() {
+	cs.keepAliveLock.Lock()
+	defer cs.keepAliveLock.Unlock()
+	if cs.keepAlive != nil {
+		close(cs.keepAlive)
+		cs.keepAlive = nil
+	}
+}
@@ -0,0 +1,50 @@
+package headlessChrome
+
+import (
+	"testing"
+	"time"
+)
+
+// TestKeepAliveRestart tests that starting a keep-alive again replaces the
+// running one and that stopping is safe to repeat
+func TestKeepAliveRestart(t *testing.T) {
+	cs := &ChromeSession{done: make(chan struct{})}
+	cs.StartKeepAlive("", time.Hour)
+	first := cs.keepAlive
+	cs.StartKeepAlive("", time.Hour)
+
+	select {
+	case <-first:
+	default:
+		t.Fatal("first keep-alive was not stopped when a second started")
+	}
+
+	cs.StopKeepAlive()
+	cs.StopKeepAlive()
+	if cs.keepAlive != nil {
+		t.Fatal("keep-alive still set after StopKeepAlive")
+	}
+}
+
+// TestKeepAliveBadInterval tests that an interval that is not positive is
+// ignored instead of panicking and leaves the running keep-alive alone
+func TestKeepAliveBadInterval(t *testing.T) {
+	cs := &ChromeSession{done: make(chan struct{})}
+	cs.StartKeepAlive("", 0)
+	if cs.keepAlive != nil {
+		t.Fatal("keep-alive started with a zero interval")
+	}
+
+	cs.StartKeepAlive("", time.Hour)
+	running := cs.keepAlive
+	cs.StartKeepAlive("", -time.Second)
+	if cs.keepAlive != running {
+		t.Fatal("negative interval replaced the running keep-alive")
+	}
+	select {
+	case <-running:
+		t.Fatal("negative interval stopped the running keep-alive")
+	default:
+	}
+	cs.StopKeepAlive()
+}
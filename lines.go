@@ -0,0 +1,26 @@
+//go:build go1.23
+
+package headlessChrome
+
+import (
+	"context"
+	"iter"
+)
+
+// Lines returns an iterator over the session's output that ends once
+// chrome exits and all of its output has been read.  It reads from the
+// same channel as Output, so use one or the other.
+//
+//	for line := range browser.Lines() {
+//		fmt.Println(line)
+//	}
+func (cs *ChromeSession) Lines() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		for {
+			line, err := cs.ReadLine(context.Background())
+			if err != nil || !yield(line) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,24 @@
+//go:build go1.23
+
+package headlessChrome
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLines tests ranging over session output until it closes
+func TestLines(t *testing.T) {
+	cs := &ChromeSession{Output: make(chan string, 2)}
+	cs.Output <- "one"
+	cs.Output <- "two"
+	close(cs.Output)
+
+	var lines []string
+	for line := range cs.Lines() {
+		lines = append(lines, line)
+	}
+	if !reflect.DeepEqual(lines, []string{"one", "two"}) {
+		t.Fatalf("Lines() yielded %v", lines)
+	}
+}
@@ -0,0 +1,193 @@
+package headlessChrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"strconv"
+	"time"
+)
+
+// NavigationTimeout is how long Navigate waits for a new page to load
+// unless WithNavigationTimeout is used
+var NavigationTimeout = time.Second * 30
+
+// NavOption configures a single call to Navigate.  Settings made at
+// launch with an Option, such as the window size or user agent, are
+// fixed for the life of the chrome process.  A NavOption only affects the
+// navigation it is passed to, so one session can be reused for different
+// kinds of pages.
+type NavOption func(*navOptions)
+
+// navOptions holds the settings for a single navigation
+type navOptions struct {
+	timeout      time.Duration
+	pollInterval time.Duration
+}
+
+// WithNavigationTimeout sets how long Navigate waits for the new page to
+// finish loading
+func WithNavigationTimeout(d time.Duration) NavOption {
+	return func(o *navOptions) {
+		o.timeout = d
+	}
+}
+
+// WithNavigationPollInterval sets how often Navigate checks if the new page
+// has loaded, overriding the session's poll interval
+func WithNavigationPollInterval(d time.Duration) NavOption {
+	return func(o *navOptions) {
+		o.pollInterval = d
+	}
+}
+
+// Navigate points the session at a new url and waits until the new page
+// has finished loading.  Navigating to a #fragment of the current page
+// does not load a new page and will time out.
+func (cs *ChromeSession) Navigate(url string, opts ...NavOption) error {
+	return cs.replacePage(`location.href=`+jsString(url), opts)
+}
+
+// Reload reloads the current page and waits until it has finished
+// loading.  Chrome ignores the force flag of location.reload, so when
+// ignoreCache is set the document and the static scripts, stylesheets and
+// images it loaded are first fetched again with the cache bypassed, which
+// refreshes them in the cache.  Requests made by fetch or XHR are never
+// repeated, since they may change data on the server.  Resources the
+// reloaded page requests for the first time may still come from the
+// cache.
+func (cs *ChromeSession) Reload(ignoreCache bool, opts ...NavOption) error {
+	if ignoreCache {
+		_, err := cs.EvalAsync(`Promise.all([location.href].concat(performance.getEntriesByType("resource").filter(function(e){`+
+			`return ["script","link","css","img"].indexOf(e.initiatorType)!==-1}).map(function(e){return e.name})).map(function(u){`+
+			`return fetch(u,{cache:"reload",credentials:"include",mode:u.indexOf(location.origin)===0?"same-origin":"no-cors"}).catch(function(){})})).then(function(){return true})`, NavigationTimeout)
+		if err != nil {
+			return err
+		}
+	}
+	return cs.replacePage(`location.reload()`, opts)
+}
+
+// ErrNoHistory is returned by Back and Forward when there is no history
+// entry to move to
+var ErrNoHistory = errors.New("no history entry to navigate to")
+
+// Back goes to the previous page in the session's history and waits until
+// it has loaded.  Single page apps that change the url with pushState are
+// supported, since only the url changing is waited for.  ErrNoHistory is
+// returned if there is no previous entry.
+func (cs *ChromeSession) Back(opts ...NavOption) error {
+	return cs.traverseHistory(-1, opts)
+}
+
+// Forward goes to the next page in the session's history and waits until
+// it has loaded, like Back.  ErrNoHistory is returned if there is no next
+// entry.
+func (cs *ChromeSession) Forward(opts ...NavOption) error {
+	return cs.traverseHistory(1, opts)
+}
+
+// traverseHistory moves delta entries through the history and waits for
+// the url to change.  Pages restored from the back/forward cache keep
+// their javascript state, so the url is compared rather than marking the
+// page as Navigate does.  Entries that have the same url as the current
+// one can not be told apart and time out.
+func (cs *ChromeSession) traverseHistory(delta int, opts []NavOption) error {
+	o := &navOptions{
+		timeout:      NavigationTimeout,
+		pollInterval: cs.pollInterval(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// the navigation api knows if there is an entry to go to, while older
+	// chrome versions can only be asked to try
+	can := "navigation.canGoForward"
+	if delta < 0 {
+		can = "navigation.canGoBack"
+	}
+	from, err := cs.Eval(`(function(){if(window.navigation&&!` + can + `){return ""}` +
+		`var from=location.href;setTimeout(function(){history.go(` + strconv.Itoa(delta) + `)},0);return from})()`)
+	if err != nil {
+		return err
+	}
+	if from == "" {
+		return ErrNoHistory
+	}
+	return cs.waitForCondition(`location.href !== `+jsString(from)+` && document.readyState === "complete"`, o.pollInterval, o.timeout)
+}
+
+// replacePage runs a javascript statement that loads a new page in place
+// of the current one and waits until the new page has finished loading
+func (cs *ChromeSession) replacePage(action string, opts []NavOption) error {
+	o := &navOptions{
+		timeout:      NavigationTimeout,
+		pollInterval: cs.pollInterval(),
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	// mark the current page so we can tell when it has been replaced, and
+	// navigate after the eval returns so the console is not cut off
+	_, err := cs.Eval(`(function(){window.__navigating=true;setTimeout(function(){` + action + `},0);return true})()`)
+	if err != nil {
+		return err
+	}
+	return cs.waitForCondition(`!window.__navigating && document.readyState === "complete"`, o.pollInterval, o.timeout)
+}
+
+// Fetch navigates to a url, waits for the page to finish loading and
+// returns its rendered HTML.  The timeout covers both the navigation and
+// reading the HTML.  Content that page scripts add after the load event
+// may be missing, so follow Navigate with one of the Wait helpers instead
+// when a page keeps rendering after it loads.
+func (cs *ChromeSession) Fetch(url string, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err := cs.Navigate(url, WithNavigationTimeout(timeout))
+	if err != nil {
+		return "", err
+	}
+	html, err := cs.EvalContext(ctx, `document.documentElement.outerHTML`)
+	if err == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %v reading the html of %s", timeout, url)
+	}
+	return html, err
+}
+
+// HasInterstitial reports whether the session is showing one of chrome's
+// own error or warning pages, such as a certificate warning, instead of
+// the page that was requested
+func (cs *ChromeSession) HasInterstitial() (bool, error) {
+	return cs.EvalBool(`location.protocol === "chrome-error:" || document.querySelector("#main-frame-error, .interstitial-wrapper") !== null`)
+}
+
+// ResponseHeaders returns the response headers for the url the session is
+// on, with keys in canonical form such as "Content-Security-Policy".  The
+// console can not see devtools network events, so the url is requested
+// again from the page with fetch.  The headers are those of that second
+// response, which matches the original for most pages but may differ for
+// pages that vary per request.  Set-Cookie is never visible to fetch.
+func (cs *ChromeSession) ResponseHeaders() (map[string]string, error) {
+	raw, err := cs.EvalAsync(`fetch(location.href,{credentials:"include",cache:"no-store"}).then(function(r){`+
+		`var h={};r.headers.forEach(function(v,k){h[k]=v});return JSON.stringify(h)})`, NavigationTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	lower := map[string]string{}
+	err = json.Unmarshal([]byte(raw), &lower)
+	if err != nil {
+		return nil, err
+	}
+	headers := make(map[string]string, len(lower))
+	for key, value := range lower {
+		headers[textproto.CanonicalMIMEHeaderKey(key)] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,39 @@
+package headlessChrome
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestReload tests that Reload reloads the page and waits for the new one
+// to load
+func TestReload(t *testing.T) {
+	fake := newFakeConsole(func(input string) []string {
+		return []string{`{"result":{"type":"boolean","value":true}}`}
+	})
+	cs := newFakeSession(t, fake)
+	defer cs.Exit()
+
+	err := cs.Reload(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.inputs) != 2 || !strings.Contains(fake.inputs[0], "location.reload()") || !strings.Contains(fake.inputs[1], "!window.__navigating") {
+		t.Fatalf("inputs = %q, want a reload then a wait for the new page", fake.inputs)
+	}
+}
+
+// TestBackWithoutHistory tests that going back from the first page fails
+// with ErrNoHistory
+func TestBackWithoutHistory(t *testing.T) {
+	fake := newFakeConsole(func(input string) []string {
+		return []string{`{"result":{"type":"string","value":""}}`}
+	})
+	cs := newFakeSession(t, fake)
+	defer cs.Exit()
+
+	err := cs.Back()
+	if err != ErrNoHistory {
+		t.Fatalf("Back() err = %v, want %v", err, ErrNoHistory)
+	}
+}
@@ -0,0 +1,161 @@
+package headlessChrome
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// oneShotArgs builds the arguments for running chrome once against a url
+// without the interactive console.  Extra args are added before the url.
+func (o *options) oneShotArgs(url string, extra ...string) []string {
+	args := o.chromeArgs(url)
+	oneShot := make([]string, 0, len(args)+len(extra))
+	for _, arg := range args[:len(args)-1] {
+		if arg != "--repl" {
+			oneShot = append(oneShot, arg)
+		}
+	}
+	oneShot = append(oneShot, extra...)
+	return append(oneShot, url)
+}
+
+// runOneShot runs chrome once with the supplied args and returns what it
+// printed to stdout.  Chrome is killed if it does not finish within the
+// startup timeout.
+func runOneShot(o *options, args []string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.startupTimeout)
+	defer cancel()
+
+	debug(ChromePath, args)
+	out, err := exec.CommandContext(ctx, ChromePath, args...).Output()
+	if err == nil {
+		return out, nil
+	}
+
+	startupErr := &StartupError{Path: ChromePath, Args: args, Err: err}
+	if ctx.Err() == context.DeadlineExceeded {
+		startupErr.Err = fmt.Errorf("%w after %v", ErrStartupTimeout, o.startupTimeout)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		startupErr.Output = strings.Split(strings.TrimSpace(string(exitErr.Stderr)), "\n")
+	}
+	return out, startupErr
+}
+
+// DumpDOM loads a url in chrome once and returns the rendered HTML of the
+// page without starting an interactive console.  Chrome is killed if it
+// does not finish within the startup timeout (see WithStartupTimeout).
+func DumpDOM(url string, opts ...Option) (string, error) {
+	o := newOptions(opts)
+	if o.err != nil {
+		return "", o.err
+	}
+	tempDir, err := o.prepareProfile()
+	if err != nil {
+		return "", err
+	}
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+
+	out, err := runOneShot(o, o.oneShotArgs(url, "--dump-dom"))
+	return string(out), err
+}
+
+// defaultWindowWidth is the width chrome uses when no window size is set
+const defaultWindowWidth = 800
+
+// CaptureScreenshot loads a url in chrome once and saves a PNG screenshot
+// of it to outPath without starting an interactive console.  Use
+// WithWindowSize to set the size of the capture and WithFullPage to
+// capture the whole page rather than just the window.
+func CaptureScreenshot(url, outPath string, opts ...Option) error {
+	o := newOptions(opts)
+	if o.err != nil {
+		return o.err
+	}
+
+	if o.fullPage {
+		height, err := measurePageHeight(url, opts)
+		if err != nil {
+			return err
+		}
+		if o.windowWidth <= 0 {
+			o.windowWidth = defaultWindowWidth
+		}
+		o.windowHeight = height
+	}
+
+	tempDir, err := o.prepareProfile()
+	if err != nil {
+		return err
+	}
+	if tempDir != "" {
+		defer os.RemoveAll(tempDir)
+	}
+
+	_, err = runOneShot(o, o.oneShotArgs(url, "--screenshot="+outPath))
+	return err
+}
+
+// measurePageHeight loads a url in a console session and returns the
+// full scrollable height of the page
+func measurePageHeight(url string, opts []Option) (int, error) {
+	browser, err := NewBrowser(url, opts...)
+	if err != nil {
+		return 0, err
+	}
+	defer browser.Exit()
+
+	height, err := browser.Eval(`Math.max(document.documentElement.scrollHeight, document.body ? document.body.scrollHeight : 0)`)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(height)
+}
+
+// ScreenshotFullPage returns a PNG screenshot of the entire page the
+// session is on, including everything below the fold.  The console can
+// not take screenshots itself, so the page's url and size are read from
+// the session and a separate one shot chrome started with the same
+// options captures it.  Page state that is not in the url, such as form
+// input, is not carried over.  Sessions started WithProfile can not use
+// this while running because chrome locks the profile directory.
+func (cs *ChromeSession) ScreenshotFullPage() ([]byte, error) {
+	raw, err := cs.Eval(`JSON.stringify({url:location.href,` +
+		`width:Math.max(document.documentElement.scrollWidth,window.innerWidth),` +
+		`height:Math.max(document.documentElement.scrollHeight,document.body?document.body.scrollHeight:0)})`)
+	if err != nil {
+		return nil, err
+	}
+	page := struct {
+		URL    string `json:"url"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+	}{}
+	err = json.Unmarshal([]byte(raw), &page)
+	if err != nil {
+		return nil, err
+	}
+
+	outFile, err := os.CreateTemp("", "headlessChrome*.png")
+	if err != nil {
+		return nil, err
+	}
+	outFile.Close()
+	defer os.Remove(outFile.Name())
+
+	opts := append(append([]Option{}, cs.opts...), WithWindowSize(page.Width, page.Height))
+	err = CaptureScreenshot(page.URL, outFile.Name(), opts...)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(outFile.Name())
+}
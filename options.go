@@ -0,0 +1,581 @@
+package headlessChrome
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Option configures a browser session before chrome is started
+type Option func(*options)
+
+// options holds the settings used to start a single browser session
+type options struct {
+	startupTimeout   time.Duration
+	args             []string // args added to the package level Args
+	userDataDir      string   // profile directory passed as --user-data-dir
+	needsUserDataDir bool     // a temporary profile is made if no userDataDir is set
+	windowWidth      int
+	windowHeight     int
+	fullPage         bool // screenshots capture the whole page height
+	headful          bool // --headless is left out so a window is shown
+	output           chan string
+	closeOutput      bool
+	name             string
+	disableFeatures  []string
+	enableFeatures   []string
+	blinkSettings    []string
+	hostRules        []string
+	replProtocol     REPLProtocol
+	pollInterval     time.Duration
+	maxLifetime      time.Duration
+	preferences      map[string]interface{}
+	downloadDir      string
+	debuggingPort    int
+	overflow         OverflowPolicy
+	scaleFactor      float64
+	stealth          bool
+	echo             EchoMode
+	stateHook        func(from, to SessionState)
+	trace            io.Writer
+	err              error // the first invalid option, reported before chrome starts
+}
+
+// newOptions builds the settings for a session from the package level
+// defaults and then applies any supplied Options over top of them
+func newOptions(opts []Option) *options {
+	o := &options{
+		startupTimeout: BrowserStartupTime,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithStartupTimeout limits how long chrome has to start and print its
+// console banner.  If the banner does not arrive in time, chrome is killed
+// and ErrStartupTimeout is returned.  Defaults to BrowserStartupTime.  The
+// timeout must be positive.
+func WithStartupTimeout(d time.Duration) Option {
+	return func(o *options) {
+		if d <= 0 {
+			o.setErr(errors.New("startup timeout must be positive, not " + d.String()))
+			return
+		}
+		o.startupTimeout = d
+	}
+}
+
+// chromeArgs builds the full list of arguments used to start chrome
+// pointed at the supplied url
+func (o *options) chromeArgs(url string) []string {
+	args := make([]string, 0, len(Args)+len(o.args)+2)
+	for _, arg := range Args {
+		if o.headful && arg == "--headless" {
+			continue
+		}
+		args = append(args, arg)
+	}
+	args = append(args, o.args...)
+	if o.userDataDir != "" && !hasArg(args, "--user-data-dir") {
+		args = append(args, "--user-data-dir="+o.userDataDir)
+	}
+	if len(o.disableFeatures) > 0 {
+		args = append(args, "--disable-features="+strings.Join(o.disableFeatures, ","))
+	}
+	if len(o.enableFeatures) > 0 {
+		args = append(args, "--enable-features="+strings.Join(o.enableFeatures, ","))
+	}
+	if len(o.hostRules) > 0 {
+		args = append(args, "--host-resolver-rules="+strings.Join(o.hostRules, ","))
+	}
+	if len(o.blinkSettings) > 0 {
+		args = append(args, "--blink-settings="+strings.Join(o.blinkSettings, ","))
+	}
+	if o.scaleFactor > 0 {
+		args = append(args, "--force-device-scale-factor="+strconv.FormatFloat(o.scaleFactor, 'f', -1, 64))
+	}
+	if o.windowWidth > 0 && o.windowHeight > 0 {
+		args = append(args, "--window-size="+strconv.Itoa(o.windowWidth)+","+strconv.Itoa(o.windowHeight))
+	}
+	return append(args, url)
+}
+
+// makeTempUserDataDir creates a throwaway profile directory when an option
+// needs one and no directory was supplied.  The directory made is returned
+// so it can be removed later, or an empty string if none was needed.
+func (o *options) makeTempUserDataDir() (string, error) {
+	if !o.needsUserDataDir || o.userDataDir != "" {
+		return "", nil
+	}
+	dir, err := os.MkdirTemp("", "headlessChrome")
+	if err != nil {
+		return "", err
+	}
+	o.userDataDir = dir
+	return dir, nil
+}
+
+// hasArg checks if a flag is already present in a list of arguments,
+// either on its own or with a value
+func hasArg(args []string, flag string) bool {
+	for _, arg := range args {
+		if arg == flag || strings.HasPrefix(arg, flag+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// WithDisableWebSecurity starts chrome with --disable-web-security, which
+// turns off the same-origin policy and CORS checks.  Chrome ignores the
+// flag unless a --user-data-dir is also set, so a temporary profile is
+// created for the session and removed when it exits.
+//
+// WARNING: Pages loaded this way can read data from any origin, including
+// sites you are logged in to.  Only use this when testing against pages
+// you control.  Never use it for general browsing.
+func WithDisableWebSecurity() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--disable-web-security")
+		o.needsUserDataDir = true
+	}
+}
+
+// WithRemoteAllowOrigins sets --remote-allow-origins, which Chrome 111 and
+// newer require before they accept devtools websocket connections from
+// another tool.  This only matters when a --remote-debugging-port is added
+// to Args.  With no origins supplied, connections from any origin ("*")
+// are allowed.
+func WithRemoteAllowOrigins(origins ...string) Option {
+	return func(o *options) {
+		if len(origins) == 0 {
+			origins = []string{"*"}
+		}
+		o.args = append(o.args, "--remote-allow-origins="+strings.Join(origins, ","))
+	}
+}
+
+// WithWindowSize sets the size of the browser window in pixels
+func WithWindowSize(width, height int) Option {
+	return func(o *options) {
+		o.windowWidth = width
+		o.windowHeight = height
+	}
+}
+
+// WithFullPage makes CaptureScreenshot capture the full height of the page
+// instead of only what fits in the window.  The page is loaded once first
+// to measure its height.
+func WithFullPage() Option {
+	return func(o *options) {
+		o.fullPage = true
+	}
+}
+
+// WithProfile starts chrome with a caller managed profile directory as its
+// --user-data-dir.  Cookies, logins and other state saved in the profile
+// persist between sessions, and the directory is never deleted.  This is
+// different from the temporary profiles made by options such as
+// WithDisableWebSecurity, which are removed when the session exits.  When a
+// profile is set, those options use it instead of making a temporary one.
+func WithProfile(dir string) Option {
+	return func(o *options) {
+		o.userDataDir = dir
+	}
+}
+
+// WithWindowPosition sets the position of the browser window on screen.
+// This is only useful when headless mode is turned off with WithHeadless.
+func WithWindowPosition(x, y int) Option {
+	return func(o *options) {
+		o.args = append(o.args, "--window-position="+strconv.Itoa(x)+","+strconv.Itoa(y))
+	}
+}
+
+// WithHeadless controls whether chrome runs headless.  Passing false leaves
+// out the --headless flag so a visible window opens, which is useful for
+// watching what a session does while debugging.  The console banner and
+// prompt are expected to behave the same as in headless mode, but --repl is
+// a headless flag and chrome builds that ignore it without --headless will
+// never print the banner, causing startup to fail with ErrStartupTimeout.
+func WithHeadless(headless bool) Option {
+	return func(o *options) {
+		o.headful = !headless
+	}
+}
+
+// WithQuietStartup adds flags that stop chrome from running first run
+// setup, checking if it is the default browser, and installing default
+// apps.  These add noise to the output on fresh profiles, such as on CI.
+func WithQuietStartup() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--no-first-run", "--no-default-browser-check", "--disable-default-apps")
+	}
+}
+
+// WithOutputChannel sends the session's output to a channel you own
+// instead of one made by the package, for example to fan the output of
+// several sessions into one consumer.  The channel is only closed when the
+// session ends if closeWhenDone is true, so leave it false when the channel
+// is shared to avoid closing it twice.  Eval and the helpers built on it
+// read results from this channel, so they should not be used on sessions
+// that share one.
+func WithOutputChannel(ch chan string, closeWhenDone bool) Option {
+	return func(o *options) {
+		o.output = ch
+		o.closeOutput = closeWhenDone
+	}
+}
+
+// WithVirtualTimeBudget runs the page on chrome's virtual clock for up to
+// ms milliseconds before it is considered loaded, and draws every frame
+// fully.  Timers and animations then finish the same way on every run,
+// which keeps screenshots and dumps deterministic.  This mainly affects
+// the one shot DumpDOM and CaptureScreenshot helpers.
+func WithVirtualTimeBudget(ms int) Option {
+	return func(o *options) {
+		o.args = append(o.args, "--run-all-compositor-stages-before-draw", "--virtual-time-budget="+strconv.Itoa(ms))
+	}
+}
+
+// WithName names the session.  The name tags all of the session's debug
+// output, which makes it possible to tell many sessions apart.  Sessions
+// without a name are given a short random one.
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithDisableFeatures turns off chrome features by name.  Features from
+// every use of this option are joined into one --disable-features flag,
+// because chrome only honors the last one it is given.
+func WithDisableFeatures(features ...string) Option {
+	return func(o *options) {
+		o.disableFeatures = append(o.disableFeatures, features...)
+	}
+}
+
+// WithEnableFeatures turns on chrome features by name.  Features from
+// every use of this option are joined into one --enable-features flag.
+func WithEnableFeatures(features ...string) Option {
+	return func(o *options) {
+		o.enableFeatures = append(o.enableFeatures, features...)
+	}
+}
+
+// WithNoSandbox starts chrome with its sandbox turned off, which is needed
+// to run as root, such as inside most docker containers
+func WithNoSandbox() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--no-sandbox")
+	}
+}
+
+// withPopupsAllowed turns off chrome's popup blocker, which NewBrowserMulti
+// needs to open its tabs with window.open
+func withPopupsAllowed() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--disable-popup-blocking")
+	}
+}
+
+// WithREPLProtocol replaces how the session reads the chrome console's
+// banner, prompts and results.  Sessions use DefaultREPLProtocol unless
+// this is set.
+func WithREPLProtocol(p REPLProtocol) Option {
+	return func(o *options) {
+		o.replProtocol = p
+	}
+}
+
+// WithPollInterval sets how often the session's waiting helpers check the
+// page.  A short interval notices changes sooner but costs more CPU, which
+// adds up with many sessions.  Defaults to PollInterval.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = d
+	}
+}
+
+// WithMaxLifetime exits the session once it has been running for d, no
+// matter if it is still in use.  This is a hard cap meant as a safety net
+// for long running programs, not an idle timeout.
+func WithMaxLifetime(d time.Duration) Option {
+	return func(o *options) {
+		o.maxLifetime = d
+	}
+}
+
+// WithProxyPAC routes chrome's traffic using the proxy auto-config script
+// at pacURL, which must be an http, https, file or data url.  It can not
+// be combined with a --proxy-server flag, which chrome would use instead.
+func WithProxyPAC(pacURL string) Option {
+	return func(o *options) {
+		u, err := url.Parse(pacURL)
+		if err != nil {
+			o.setErr(err)
+			return
+		}
+		switch u.Scheme {
+		case "http", "https", "file", "data":
+		default:
+			o.setErr(errors.New("proxy PAC url must use http, https, file or data, not " + pacURL))
+			return
+		}
+		o.args = append(o.args, "--proxy-pac-url="+pacURL)
+	}
+}
+
+// setErr records an invalid option.  Only the first error is kept.
+func (o *options) setErr(err error) {
+	if o.err == nil {
+		o.err = err
+	}
+}
+
+// WithAcceptLanguage sets the languages chrome sends in the
+// Accept-Language request header, in order of preference, such as
+// WithAcceptLanguage("fr-CA", "fr", "en").  This is separate from the
+// --lang flag, which sets the browser's UI language and does not always
+// change the header.  The console can not reach the devtools network
+// domain, so the header is fixed when chrome starts.
+func WithAcceptLanguage(langs ...string) Option {
+	return func(o *options) {
+		o.args = append(o.args, "--accept-lang="+strings.Join(langs, ","))
+	}
+}
+
+// WithIgnoreCertErrors makes chrome load https pages even when their
+// certificates are invalid, such as self-signed certificates on staging
+// sites.
+//
+// WARNING: This disables the protection https gives against someone
+// intercepting or changing traffic.  Only use it for hosts you control.
+func WithIgnoreCertErrors() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--ignore-certificate-errors")
+	}
+}
+
+// OverflowPolicy decides what happens to new output when the Output
+// channel is full because nothing is reading it
+type OverflowPolicy int
+
+const (
+	// OverflowBlock waits for room in the channel.  While it waits, chrome's
+	// output is not read, which can stall chrome.  This is the default.
+	OverflowBlock OverflowPolicy = iota
+	// OverflowDropOldest discards the oldest line in the channel to make
+	// room for the new one
+	OverflowDropOldest
+	// OverflowDropNewest discards the new line
+	OverflowDropNewest
+)
+
+// WithOverflowPolicy sets what happens when the Output channel fills up.
+// The drop policies keep chrome's output flowing when a consumer falls
+// behind, and count dropped lines in DroppedLines.  Dropped lines can
+// include expression results, which makes a waiting Eval time out.
+func WithOverflowPolicy(policy OverflowPolicy) Option {
+	return func(o *options) {
+		o.overflow = policy
+	}
+}
+
+// WithDeviceScaleFactor sets the device pixel ratio chrome renders at.  A
+// factor of 2 renders like a retina display, so CaptureScreenshot and
+// ScreenshotFullPage produce images at twice the window size.
+func WithDeviceScaleFactor(f float64) Option {
+	return func(o *options) {
+		o.scaleFactor = f
+	}
+}
+
+// stealthScript hides the most common signs of automation from page
+// scripts that check for them
+const stealthScript = `(function(){` +
+	`Object.defineProperty(navigator,"webdriver",{get:function(){return undefined}});` +
+	`if(!navigator.plugins.length){Object.defineProperty(navigator,"plugins",{get:function(){return [1,2,3]}})}` +
+	`if(!navigator.languages||!navigator.languages.length){Object.defineProperty(navigator,"languages",{get:function(){return ["en-US","en"]}})}` +
+	`})()`
+
+// WithStealth hides signs that chrome is being automated.  It turns off
+// the AutomationControlled blink feature, which sets navigator.webdriver,
+// and once the console is ready it overrides navigator.webdriver,
+// navigator.plugins and navigator.languages to look like a normal browser.
+// The console can not register scripts to run before each page loads, so
+// the overrides only apply to the current page and scripts that checked
+// them while it loaded are not fooled.
+func WithStealth() Option {
+	return func(o *options) {
+		o.args = append(o.args, "--disable-blink-features=AutomationControlled")
+		o.stealth = true
+	}
+}
+
+// WithColorScheme makes pages see the "light" or "dark" value for the
+// prefers-color-scheme media feature.  The chrome console has no access to
+// the devtools emulation domain, so the scheme is fixed when chrome
+// starts rather than changed on a running session.  Chrome dropped
+// support for "no-preference", so only light and dark are accepted.
+func WithColorScheme(scheme string) Option {
+	return func(o *options) {
+		// values of blink's PreferredColorScheme enum
+		switch scheme {
+		case "dark":
+			o.blinkSettings = append(o.blinkSettings, "preferredColorScheme=0")
+		case "light":
+			o.blinkSettings = append(o.blinkSettings, "preferredColorScheme=1")
+		default:
+			o.setErr(errors.New(`color scheme must be "light" or "dark", not ` + strconv.Quote(scheme)))
+		}
+	}
+}
+
+// WithMediaType makes pages render with the "screen" or "print" CSS media
+// type, so screenshots show print styles without generating a PDF.  Like
+// WithColorScheme it is applied when chrome starts.
+func WithMediaType(media string) Option {
+	return func(o *options) {
+		switch media {
+		case "screen", "print":
+			o.blinkSettings = append(o.blinkSettings, "mediaTypeOverride="+media)
+		default:
+			o.setErr(errors.New(`media type must be "screen" or "print", not ` + strconv.Quote(media)))
+		}
+	}
+}
+
+// WithBlockedHosts makes requests to matching hosts fail as if the host
+// did not exist, which is useful for keeping ads and trackers from
+// loading.  A pattern is a host name where * matches any run of
+// characters, such as "*.doubleclick.net" or "analytics.example.com".
+// Chrome resolves host names before it sees paths, so whole hosts are
+// blocked rather than individual urls.
+func WithBlockedHosts(patterns ...string) Option {
+	return func(o *options) {
+		for _, pattern := range patterns {
+			if pattern == "" || strings.ContainsAny(pattern, "/, ") {
+				o.setErr(errors.New("blocked host must be a host name pattern, not " + strconv.Quote(pattern)))
+				return
+			}
+			o.hostRules = append(o.hostRules, "MAP "+pattern+" ~NOTFOUND")
+		}
+	}
+}
+
+// EchoMode decides what Eval does with a console line that repeats the
+// expression it just wrote
+type EchoMode int
+
+const (
+	// EchoKeep treats an echo like any other output, so it shows up in the
+	// logs from EvalWithLogs and the lines from EvalCollect.  This is the
+	// default.
+	EchoKeep EchoMode = iota
+	// EchoStrip drops the first line that exactly matches the expression
+	// that was written
+	EchoStrip
+)
+
+// WithEchoHandling sets what Eval does when the console echoes an
+// expression back before its result.  Chrome's own console does not echo
+// input except as a prompt line, which is always dropped, but some
+// wrappers around it do.
+func WithEchoHandling(mode EchoMode) Option {
+	return func(o *options) {
+		o.echo = mode
+	}
+}
+
+// WithHostResolverRules overrides how chrome resolves host names, which
+// lets pages keep their production host names while talking to another
+// server, such as WithHostResolverRules("MAP api.example.com 10.0.0.5").
+// Rules are comma separated and are merged with those from
+// WithBlockedHosts into one --host-resolver-rules flag.
+func WithHostResolverRules(rules string) Option {
+	return func(o *options) {
+		o.hostRules = append(o.hostRules, rules)
+	}
+}
+
+// WithFlagsFromEnv appends chrome flags read from an environment variable,
+// such as HEADLESS_CHROME_FLAGS, so they can be tuned per deployment
+// without changing code.  The value is split into words like a shell
+// would: words are separated by spaces, tabs or newlines, single quotes
+// keep everything inside them literally, double quotes keep spaces, and a
+// backslash outside single quotes makes the next character literal.
+// Variables and globs are not expanded.  An unset or empty variable adds
+// nothing.
+func WithFlagsFromEnv(varName string) Option {
+	return func(o *options) {
+		flags, err := splitFlags(os.Getenv(varName))
+		if err != nil {
+			o.setErr(fmt.Errorf("reading chrome flags from %s: %w", varName, err))
+			return
+		}
+		o.args = append(o.args, flags...)
+	}
+}
+
+// splitFlags splits a string into words using shell quoting rules
+func splitFlags(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var inWord, escaped bool
+	var quote rune
+	for _, r := range s {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' {
+				escaped = true
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			escaped = true
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unterminated " + string(quote) + " quote")
+	}
+	if escaped {
+		return nil, errors.New("trailing backslash")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -0,0 +1,211 @@
+package headlessChrome
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestChromeArgs tests that options are turned into the right chrome arguments
+func TestChromeArgs(t *testing.T) {
+	o := newOptions([]Option{WithDisableWebSecurity()})
+	o.userDataDir = "/tmp/profile"
+
+	want := append(append([]string{}, Args...), "--disable-web-security", "--user-data-dir=/tmp/profile", "https://example.com")
+	got := o.chromeArgs("https://example.com")
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("chromeArgs() = %v, want %v", got, want)
+	}
+
+	// building args must never modify the package level defaults
+	if hasArg(Args, "--disable-web-security") {
+		t.Fatal("chromeArgs modified the package level Args")
+	}
+}
+
+// TestOneShotArgs tests that one shot runs drop the console flag and add
+// their own flags before the url
+func TestOneShotArgs(t *testing.T) {
+	o := newOptions(nil)
+	args := o.oneShotArgs("https://example.com", "--dump-dom")
+
+	if hasArg(args, "--repl") {
+		t.Fatalf("oneShotArgs() = %v, should not contain --repl", args)
+	}
+	n := len(args)
+	if n < 2 || args[n-2] != "--dump-dom" || args[n-1] != "https://example.com" {
+		t.Fatalf("oneShotArgs() = %v, want --dump-dom followed by the url at the end", args)
+	}
+}
+
+// TestWindowSizeArg tests that the window size is passed to chrome
+func TestWindowSizeArg(t *testing.T) {
+	o := newOptions([]Option{WithWindowSize(1024, 768)})
+	if !hasArg(o.chromeArgs("https://example.com"), "--window-size=1024,768") {
+		t.Fatal("chromeArgs() is missing --window-size=1024,768")
+	}
+}
+
+// TestProfileIsNotTemporary tests that a supplied profile is used in place
+// of a temporary one
+func TestProfileIsNotTemporary(t *testing.T) {
+	o := newOptions([]Option{WithDisableWebSecurity(), WithProfile("/tmp/profile")})
+	tempDir, err := o.makeTempUserDataDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tempDir != "" {
+		t.Fatalf("makeTempUserDataDir() made %s when a profile was supplied", tempDir)
+	}
+	if !hasArg(o.chromeArgs("https://example.com"), "--user-data-dir=/tmp/profile") {
+		t.Fatal("chromeArgs() is missing the supplied profile")
+	}
+}
+
+// TestHeadful tests that turning off headless mode drops the flag
+func TestHeadful(t *testing.T) {
+	o := newOptions([]Option{WithHeadless(false)})
+	if hasArg(o.chromeArgs("https://example.com"), "--headless") {
+		t.Fatal("chromeArgs() contains --headless with WithHeadless(false)")
+	}
+}
+
+// TestFeatureFlags tests that features from several options are merged
+// into a single flag
+func TestFeatureFlags(t *testing.T) {
+	o := newOptions([]Option{
+		WithDisableFeatures("Translate"),
+		WithDisableFeatures("MediaRouter", "OptimizationHints"),
+		WithEnableFeatures("NetworkService"),
+	})
+	args := o.chromeArgs("https://example.com")
+
+	var disableFlags int
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "--disable-features") {
+			disableFlags++
+		}
+	}
+	if disableFlags != 1 || !hasArg(args, "--disable-features=Translate,MediaRouter,OptimizationHints") {
+		t.Fatalf("chromeArgs() = %v, want one merged --disable-features flag", args)
+	}
+	if !hasArg(args, "--enable-features=NetworkService") {
+		t.Fatalf("chromeArgs() = %v, missing --enable-features", args)
+	}
+}
+
+// TestProxyPAC tests that PAC urls are validated
+func TestProxyPAC(t *testing.T) {
+	o := newOptions([]Option{WithProxyPAC("https://example.com/proxy.pac")})
+	if o.err != nil || !hasArg(o.chromeArgs("https://example.com"), "--proxy-pac-url=https://example.com/proxy.pac") {
+		t.Fatalf("valid PAC url was not passed to chrome: %v", o.err)
+	}
+
+	o = newOptions([]Option{WithProxyPAC("ftp://example.com/proxy.pac")})
+	if o.err == nil {
+		t.Fatal("ftp PAC url was accepted")
+	}
+}
+
+// TestBuildCommand tests that the built command matches the session args
+func TestBuildCommand(t *testing.T) {
+	cmd, err := BuildCommand("https://example.com", WithWindowSize(1024, 768))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := newOptions([]Option{WithWindowSize(1024, 768)}).chromeArgs("https://example.com")
+	if !reflect.DeepEqual(cmd.Args[1:], want) {
+		t.Fatalf("BuildCommand() args = %v, want %v", cmd.Args[1:], want)
+	}
+	if cmd.Process != nil {
+		t.Fatal("BuildCommand() started the command")
+	}
+}
+
+// TestColorScheme tests that color schemes are validated and passed to
+// chrome as a blink setting
+func TestColorScheme(t *testing.T) {
+	o := newOptions([]Option{WithColorScheme("dark")})
+	if o.err != nil || !hasArg(o.chromeArgs("https://example.com"), "--blink-settings=preferredColorScheme=0") {
+		t.Fatalf("dark color scheme was not passed to chrome: %v", o.err)
+	}
+
+	o = newOptions([]Option{WithColorScheme("no-preference")})
+	if o.err == nil {
+		t.Fatal("expected an error for an unsupported color scheme")
+	}
+}
+
+// TestMediaType tests that media types share one --blink-settings flag
+// with the color scheme
+func TestMediaType(t *testing.T) {
+	o := newOptions([]Option{WithColorScheme("light"), WithMediaType("print")})
+	if o.err != nil || !hasArg(o.chromeArgs("https://example.com"), "--blink-settings=preferredColorScheme=1,mediaTypeOverride=print") {
+		t.Fatalf("chromeArgs() = %v, want merged blink settings: %v", o.chromeArgs("https://example.com"), o.err)
+	}
+
+	o = newOptions([]Option{WithMediaType("tv")})
+	if o.err == nil {
+		t.Fatal("expected an error for an unsupported media type")
+	}
+}
+
+// TestBlockedHosts tests that blocked hosts become host resolver rules
+func TestBlockedHosts(t *testing.T) {
+	o := newOptions([]Option{WithBlockedHosts("*.doubleclick.net", "analytics.example.com")})
+	if o.err != nil || !hasArg(o.chromeArgs("https://example.com"), "--host-resolver-rules=MAP *.doubleclick.net ~NOTFOUND,MAP analytics.example.com ~NOTFOUND") {
+		t.Fatalf("chromeArgs() = %v, want host resolver rules: %v", o.chromeArgs("https://example.com"), o.err)
+	}
+
+	o = newOptions([]Option{WithBlockedHosts("https://example.com/ads/*")})
+	if o.err == nil {
+		t.Fatal("expected an error for a url pattern")
+	}
+}
+
+// TestHostResolverRules tests that resolver rules share one flag with
+// blocked hosts
+func TestHostResolverRules(t *testing.T) {
+	o := newOptions([]Option{WithHostResolverRules("MAP api.example.com 127.0.0.1:8080"), WithBlockedHosts("ads.example.com")})
+	if !hasArg(o.chromeArgs("https://example.com"), "--host-resolver-rules=MAP api.example.com 127.0.0.1:8080,MAP ads.example.com ~NOTFOUND") {
+		t.Fatalf("chromeArgs() = %v, want merged host resolver rules", o.chromeArgs("https://example.com"))
+	}
+}
+
+// TestFlagsFromEnv tests that flags are split using shell quoting
+func TestFlagsFromEnv(t *testing.T) {
+	t.Setenv("TEST_CHROME_FLAGS", `--no-first-run  --user-agent="my agent \"v2\"" '--lang=en US' --path=a\ b`)
+	o := newOptions([]Option{WithFlagsFromEnv("TEST_CHROME_FLAGS")})
+	want := []string{"--no-first-run", `--user-agent=my agent "v2"`, "--lang=en US", "--path=a b"}
+	if o.err != nil || !reflect.DeepEqual(o.args, want) {
+		t.Fatalf("args = %q, want %q: %v", o.args, want, o.err)
+	}
+
+	t.Setenv("TEST_CHROME_FLAGS", `--user-agent="unterminated`)
+	o = newOptions([]Option{WithFlagsFromEnv("TEST_CHROME_FLAGS")})
+	if o.err == nil {
+		t.Fatal("expected an error for an unterminated quote")
+	}
+
+	o = newOptions([]Option{WithFlagsFromEnv("TEST_CHROME_FLAGS_UNSET")})
+	if o.err != nil || len(o.args) != 0 {
+		t.Fatalf("unset variable added args %q: %v", o.args, o.err)
+	}
+}
+
+// TestWithStartupTimeout tests that a startup timeout that is not positive is
+// rejected instead of failing every start at once
+func TestWithStartupTimeout(t *testing.T) {
+	o := newOptions([]Option{WithStartupTimeout(time.Minute)})
+	if o.err != nil || o.startupTimeout != time.Minute {
+		t.Fatalf("startupTimeout = %v, want %v: %v", o.startupTimeout, time.Minute, o.err)
+	}
+
+	for _, d := range []time.Duration{0, -time.Second} {
+		o = newOptions([]Option{WithStartupTimeout(d)})
+		if o.err == nil {
+			t.Fatalf("expected an error for a startup timeout of %v", d)
+		}
+	}
+}
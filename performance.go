@@ -0,0 +1,69 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PerfTiming holds navigation timing for the current page.  Every value is
+// measured from the start of the navigation and is zero when the page has
+// not reached that point yet.
+type PerfTiming struct {
+	DNSLookup        time.Duration // time spent resolving the host
+	Connect          time.Duration // time spent opening the connection
+	TTFB             time.Duration // time until the first response byte
+	ResponseEnd      time.Duration // time until the last response byte
+	DOMInteractive   time.Duration // time until the document was parsed
+	DOMContentLoaded time.Duration // time until DOMContentLoaded handlers finished
+	Load             time.Duration // time until load handlers finished
+}
+
+// perfTimingScript reads the navigation timing entry, falling back to the
+// older performance.timing object, and reports each mark in milliseconds
+// since the navigation started
+const perfTimingScript = `(function(){var t=performance.getEntriesByType("navigation")[0],s=0;` +
+	`if(!t){t=performance.timing;s=t.navigationStart}` +
+	`function m(v){return v>s?v-s:0}` +
+	`return JSON.stringify({dns:m(t.domainLookupEnd)-m(t.domainLookupStart),connect:m(t.connectEnd)-m(t.connectStart),` +
+	`ttfb:m(t.responseStart),responseEnd:m(t.responseEnd),domInteractive:m(t.domInteractive),` +
+	`domContentLoaded:m(t.domContentLoadedEventEnd),load:m(t.loadEventEnd)})})()`
+
+// PerformanceTiming returns the navigation timing of the current page
+func (cs *ChromeSession) PerformanceTiming() (PerfTiming, error) {
+	raw, err := cs.Eval(perfTimingScript)
+	if err != nil {
+		return PerfTiming{}, err
+	}
+	return parsePerfTiming(raw)
+}
+
+// parsePerfTiming converts the millisecond values reported by
+// perfTimingScript into a PerfTiming
+func parsePerfTiming(raw string) (PerfTiming, error) {
+	ms := struct {
+		DNS              float64 `json:"dns"`
+		Connect          float64 `json:"connect"`
+		TTFB             float64 `json:"ttfb"`
+		ResponseEnd      float64 `json:"responseEnd"`
+		DOMInteractive   float64 `json:"domInteractive"`
+		DOMContentLoaded float64 `json:"domContentLoaded"`
+		Load             float64 `json:"load"`
+	}{}
+	err := json.Unmarshal([]byte(raw), &ms)
+	if err != nil {
+		return PerfTiming{}, err
+	}
+
+	toDuration := func(v float64) time.Duration {
+		return time.Duration(v * float64(time.Millisecond))
+	}
+	return PerfTiming{
+		DNSLookup:        toDuration(ms.DNS),
+		Connect:          toDuration(ms.Connect),
+		TTFB:             toDuration(ms.TTFB),
+		ResponseEnd:      toDuration(ms.ResponseEnd),
+		DOMInteractive:   toDuration(ms.DOMInteractive),
+		DOMContentLoaded: toDuration(ms.DOMContentLoaded),
+		Load:             toDuration(ms.Load),
+	}, nil
+}
@@ -0,0 +1,32 @@
+package headlessChrome
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParsePerfTiming tests that navigation timings are converted into
+// durations and that results that are not JSON are rejected
+func TestParsePerfTiming(t *testing.T) {
+	timing, err := parsePerfTiming(`{"dns":1.5,"connect":2,"ttfb":40.25,"responseEnd":45,"domInteractive":120,"domContentLoaded":130,"load":0}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := PerfTiming{
+		DNSLookup:        1500 * time.Microsecond,
+		Connect:          2 * time.Millisecond,
+		TTFB:             40250 * time.Microsecond,
+		ResponseEnd:      45 * time.Millisecond,
+		DOMInteractive:   120 * time.Millisecond,
+		DOMContentLoaded: 130 * time.Millisecond,
+	}
+	if timing != want {
+		t.Fatalf("got %+v, want %+v", timing, want)
+	}
+
+	_, err = parsePerfTiming("undefined")
+	if err == nil {
+		t.Fatal("expected an error parsing a non-JSON result")
+	}
+}
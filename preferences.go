@@ -0,0 +1,93 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// WithPreferences writes chrome preferences into the profile's Preferences
+// file before chrome starts.  This reaches settings that have no command
+// line flag, such as popup handling.  Nested settings are nested maps,
+// like {"download": {"prompt_for_download": false}}.  A temporary profile
+// is created unless one is supplied WithProfile, in which case the values
+// are merged into its existing preferences.
+func WithPreferences(prefs map[string]interface{}) Option {
+	return func(o *options) {
+		if o.preferences == nil {
+			o.preferences = map[string]interface{}{}
+		}
+		mergePreferences(o.preferences, prefs)
+		o.needsUserDataDir = true
+	}
+}
+
+// prepareProfile makes a temporary profile directory if one is needed and
+// writes any preferences into the profile.  The temporary directory made,
+// if any, is returned so it can be removed later.
+func (o *options) prepareProfile() (string, error) {
+	tempDir, err := o.makeTempUserDataDir()
+	if err != nil {
+		return "", err
+	}
+	if len(o.preferences) > 0 {
+		err = writePreferences(o.userDataDir, o.preferences)
+		if err != nil {
+			if tempDir != "" {
+				os.RemoveAll(tempDir)
+			}
+			return "", err
+		}
+	}
+	return tempDir, nil
+}
+
+// writePreferences merges preferences into the Preferences file of the
+// default profile in a user data directory
+func writePreferences(userDataDir string, prefs map[string]interface{}) error {
+	profileDir := filepath.Join(userDataDir, "Default")
+	err := os.MkdirAll(profileDir, 0700)
+	if err != nil {
+		return err
+	}
+
+	// start from the existing preferences so they are not lost
+	prefsFile := filepath.Join(profileDir, "Preferences")
+	existing := map[string]interface{}{}
+	b, err := os.ReadFile(prefsFile)
+	if err == nil {
+		err = json.Unmarshal(b, &existing)
+		if err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	mergePreferences(existing, prefs)
+	b, err = json.Marshal(existing)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(prefsFile, b, 0600)
+}
+
+// mergePreferences copies src into dst, merging nested maps instead of
+// replacing them.  Nested maps are copied rather than shared, so later
+// merges into dst never change the caller's maps.
+func mergePreferences(dst, src map[string]interface{}) {
+	for key, value := range src {
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			mergePreferences(dstMap, srcMap)
+			continue
+		}
+		if srcIsMap {
+			copied := map[string]interface{}{}
+			mergePreferences(copied, srcMap)
+			value = copied
+		}
+		dst[key] = value
+	}
+}
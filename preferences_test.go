@@ -0,0 +1,67 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestWritePreferences tests that preferences are merged into an
+// existing profile
+func TestWritePreferences(t *testing.T) {
+	dir := t.TempDir()
+	prefsFile := filepath.Join(dir, "Default", "Preferences")
+	os.MkdirAll(filepath.Dir(prefsFile), 0700)
+	err := os.WriteFile(prefsFile, []byte(`{"download":{"prompt_for_download":true,"directory_upgrade":true},"homepage":"about:blank"}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = writePreferences(dir, map[string]interface{}{
+		"download": map[string]interface{}{"prompt_for_download": false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(prefsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]interface{}{}
+	json.Unmarshal(b, &got)
+	want := map[string]interface{}{
+		"download": map[string]interface{}{"prompt_for_download": false, "directory_upgrade": true},
+		"homepage": "about:blank",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Preferences = %v, want %v", got, want)
+	}
+}
+
+// TestWithPreferencesCopies tests that merging several sets of preferences
+// does not change the maps the caller passed in
+func TestWithPreferencesCopies(t *testing.T) {
+	first := map[string]interface{}{
+		"download": map[string]interface{}{"prompt_for_download": false},
+	}
+	second := map[string]interface{}{
+		"download": map[string]interface{}{"directory_upgrade": true},
+	}
+	o := newOptions([]Option{WithPreferences(first), WithPreferences(second)})
+
+	want := map[string]interface{}{
+		"download": map[string]interface{}{"prompt_for_download": false},
+	}
+	if !reflect.DeepEqual(first, want) {
+		t.Fatalf("first preferences = %v after merging, want %v", first, want)
+	}
+	want = map[string]interface{}{
+		"download": map[string]interface{}{"prompt_for_download": false, "directory_upgrade": true},
+	}
+	if !reflect.DeepEqual(o.preferences, want) {
+		t.Fatalf("preferences = %v, want %v", o.preferences, want)
+	}
+}
@@ -0,0 +1,63 @@
+package headlessChrome
+
+import "strings"
+
+// REPLProtocol describes how to read the output of the chrome console.
+// The framing of the console has changed between chrome versions, so a
+// custom protocol can be supplied with WithREPLProtocol to adapt to a
+// version this package does not understand yet.
+type REPLProtocol interface {
+	// IsBanner reports whether a line is the banner chrome prints once
+	// the console is ready for input
+	IsBanner(line string) bool
+
+	// IsPrompt reports whether a line is a prompt, or the echo of an
+	// input, which is dropped from the session output
+	IsPrompt(line string) bool
+
+	// ParseResult parses a line as the result of an evaluated expression.
+	// It returns false if the line is not a result.
+	ParseResult(line string) (*REPLResult, bool)
+}
+
+// REPLResult is the result of evaluating an expression in the console
+type REPLResult struct {
+	Type  string // the javascript type, such as "string", "number" or "undefined"
+	Value string // the value, without quotes for strings
+	Err   error  // the exception thrown by the expression, if any
+}
+
+// DefaultREPLProtocol reads the output of the console started by chrome's
+// --repl flag, which prints a banner, prompts starting with >>> and a JSON
+// result for each expression
+var DefaultREPLProtocol REPLProtocol = chromeREPL{}
+
+// chromeREPL implements REPLProtocol for chrome's --repl console
+type chromeREPL struct{}
+
+// IsBanner implements REPLProtocol
+func (chromeREPL) IsBanner(line string) bool {
+	return strings.Contains(line, expectedFirstLine)
+}
+
+// IsPrompt implements REPLProtocol
+func (chromeREPL) IsPrompt(line string) bool {
+	return strings.HasPrefix(line, promptPrefix)
+}
+
+// ParseResult implements REPLProtocol
+func (chromeREPL) ParseResult(line string) (*REPLResult, bool) {
+	r, ok := parseResult(line)
+	if !ok {
+		return nil, false
+	}
+	return &REPLResult{Type: r.Result.Type, Value: r.value(), Err: r.err()}, true
+}
+
+// protocol returns the REPLProtocol used by the session
+func (cs *ChromeSession) protocol() REPLProtocol {
+	if cs.replProtocol == nil {
+		return DefaultREPLProtocol
+	}
+	return cs.replProtocol
+}
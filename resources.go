@@ -0,0 +1,11 @@
+package headlessChrome
+
+import "time"
+
+// ResourceStats is how much a session's chrome processes are using
+type ResourceStats struct {
+	PID       int           // the main chrome process
+	Processes int           // chrome's main process and all of its children
+	RSS       uint64        // resident memory of all processes, in bytes
+	CPUTime   time.Duration // user and system CPU time of all processes
+}
@@ -0,0 +1,140 @@
+package headlessChrome
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clockTicks is the kernel's USER_HZ, which /proc reports CPU time in.  It
+// is 100 on every common Linux platform.
+const clockTicks = 100
+
+// procStat is the part of /proc/<pid>/stat used for resource usage
+type procStat struct {
+	ppid     int
+	cpuTicks uint64
+	rssPages uint64
+}
+
+// ResourceUsage reports the memory and CPU used by the session's chrome
+// process and the renderer and helper processes it started.  The console
+// does not expose chrome's process id, so the main process is found by
+// matching its command line in /proc, and an error is returned if more
+// than one chrome was started with exactly the same command line.
+func (cs *ChromeSession) ResourceUsage() (ResourceStats, error) {
+	want := append([]string{cs.path}, cs.args...)
+	pids, err := filepath.Glob("/proc/[0-9]*")
+	if err != nil {
+		return ResourceStats{}, err
+	}
+
+	stats := map[int]procStat{}
+	mainPID := 0
+	for _, dir := range pids {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, "stat"))
+		if err != nil {
+			continue // the process exited
+		}
+		stat, err := parseProcStat(string(raw))
+		if err != nil {
+			continue
+		}
+		stats[pid] = stat
+
+		cmdline, err := os.ReadFile(filepath.Join(dir, "cmdline"))
+		if err != nil || !cmdlineMatches(string(cmdline), want) {
+			continue
+		}
+		if mainPID != 0 {
+			return ResourceStats{}, errors.New("more than one chrome process has this session's command line")
+		}
+		mainPID = pid
+	}
+	if mainPID == 0 {
+		return ResourceStats{}, fmt.Errorf("no running chrome process has this session's command line: %w", ErrSessionClosed)
+	}
+
+	usage := ResourceStats{PID: mainPID}
+	for pid, stat := range stats {
+		if !descendsFrom(pid, mainPID, stats) {
+			continue
+		}
+		usage.Processes++
+		usage.RSS += stat.rssPages * uint64(os.Getpagesize())
+		usage.CPUTime += time.Duration(stat.cpuTicks) * time.Second / clockTicks
+	}
+	return usage, nil
+}
+
+// cmdlineMatches reports whether the contents of /proc/<pid>/cmdline are
+// the command line made of args.  Chrome rewrites its process title on
+// Linux, which turns the NUL separated arguments into one space separated
+// string padded with NULs, so both forms are compared with spaces.
+func cmdlineMatches(cmdline string, args []string) bool {
+	normalize := func(s string) string {
+		return strings.TrimRight(strings.ReplaceAll(s, "\x00", " "), " ")
+	}
+	return normalize(cmdline) == normalize(strings.Join(args, " "))
+}
+
+// descendsFrom reports whether pid is ancestor or one of its descendants
+func descendsFrom(pid, ancestor int, stats map[int]procStat) bool {
+	// the depth limit guards against a parent loop from pid reuse
+	for depth := 0; depth < 64; depth++ {
+		if pid == ancestor {
+			return true
+		}
+		stat, ok := stats[pid]
+		if !ok || stat.ppid == pid {
+			return false
+		}
+		pid = stat.ppid
+	}
+	return false
+}
+
+// parseProcStat reads the parent pid, CPU time and resident pages from the
+// contents of /proc/<pid>/stat
+func parseProcStat(raw string) (procStat, error) {
+	// the command name can contain spaces and parens, so start after the
+	// last paren
+	end := strings.LastIndexByte(raw, ')')
+	if end == -1 {
+		return procStat{}, errors.New("malformed proc stat")
+	}
+	fields := strings.Fields(raw[end+1:])
+	// fields[0] is field 3 of the file, the process state
+	if len(fields) < 22 {
+		return procStat{}, errors.New("malformed proc stat")
+	}
+
+	var stat procStat
+	var err error
+	stat.ppid, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return procStat{}, err
+	}
+	utime, err := strconv.ParseUint(fields[11], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	stime, err := strconv.ParseUint(fields[12], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	stat.cpuTicks = utime + stime
+	stat.rssPages, err = strconv.ParseUint(fields[21], 10, 64)
+	if err != nil {
+		return procStat{}, err
+	}
+	return stat, nil
+}
@@ -0,0 +1,56 @@
+package headlessChrome
+
+import "testing"
+
+// TestParseProcStat tests reading a stat line whose command name has
+// spaces and parens in it
+func TestParseProcStat(t *testing.T) {
+	raw := "4242 (Chrome (Helper) x) S 4000 4242 4242 0 -1 4194560 5000 0 12 0 150 50 0 0 20 0 18 0 12345 900000000 2048 18446744073709551615\n"
+	stat, err := parseProcStat(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stat.ppid != 4000 || stat.cpuTicks != 200 || stat.rssPages != 2048 {
+		t.Fatalf("parseProcStat() = %+v", stat)
+	}
+
+	_, err = parseProcStat("4242 (chrome) S 1")
+	if err == nil {
+		t.Fatal("expected an error for a short stat line")
+	}
+}
+
+// TestDescendsFrom tests walking the process tree
+func TestDescendsFrom(t *testing.T) {
+	stats := map[int]procStat{
+		1:  {ppid: 0},
+		10: {ppid: 1},
+		11: {ppid: 10},
+		12: {ppid: 11},
+		20: {ppid: 1},
+	}
+	if !descendsFrom(12, 10, stats) || !descendsFrom(10, 10, stats) {
+		t.Fatal("descendant was not found")
+	}
+	if descendsFrom(20, 10, stats) {
+		t.Fatal("sibling was counted as a descendant")
+	}
+}
+
+// TestCmdlineMatches tests matching both the original NUL separated
+// command line and the title chrome rewrites it to
+func TestCmdlineMatches(t *testing.T) {
+	args := []string{"/usr/bin/chrome", "--headless", "--repl", "https://example.com"}
+	tests := map[string]bool{
+		"/usr/bin/chrome\x00--headless\x00--repl\x00https://example.com\x00":        true,
+		"/usr/bin/chrome --headless --repl https://example.com\x00\x00\x00\x00\x00": true,
+		"/usr/bin/chrome --headless --repl https://example.com":                     true,
+		"/usr/bin/chrome\x00--headless\x00--repl\x00https://example.org\x00":        false,
+		"/usr/bin/chrome --type=renderer --headless\x00\x00":                        false,
+	}
+	for cmdline, want := range tests {
+		if got := cmdlineMatches(cmdline, args); got != want {
+			t.Errorf("cmdlineMatches(%q) = %v, want %v", cmdline, got, want)
+		}
+	}
+}
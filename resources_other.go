@@ -0,0 +1,12 @@
+//go:build !linux
+
+package headlessChrome
+
+import "errors"
+
+// ResourceUsage reports the memory and CPU used by the session's chrome
+// processes.  It is only supported on Linux, where it reads /proc, and
+// returns an error everywhere else.
+func (cs *ChromeSession) ResourceUsage() (ResourceStats, error) {
+	return ResourceStats{}, errors.New("resource usage is only supported on linux")
+}
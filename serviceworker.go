@@ -0,0 +1,30 @@
+package headlessChrome
+
+import "encoding/json"
+
+// ServiceWorker is a service worker registered by the current page's
+// origin
+type ServiceWorker struct {
+	Scope     string `json:"scope"`     // the urls the worker controls
+	ScriptURL string `json:"scriptURL"` // the worker's script
+	State     string `json:"state"`     // such as "activated" or "installing"
+}
+
+// ServiceWorkers lists the service workers registered by the current
+// page's origin, so tests can check that a PWA installed its worker.  The
+// console only evaluates in the page, so code can not be run inside a
+// worker; talk to one with postMessage from the page if it listens for
+// messages.  Workers of other origins are listed by Targets with the
+// "service_worker" type.
+func (cs *ChromeSession) ServiceWorkers() ([]ServiceWorker, error) {
+	raw, err := cs.EvalAsync(`(navigator.serviceWorker?navigator.serviceWorker.getRegistrations():Promise.resolve([])).then(function(regs){`+
+		`return JSON.stringify(regs.map(function(r){var w=r.active||r.waiting||r.installing;`+
+		`return {scope:r.scope,scriptURL:w?w.scriptURL:"",state:w?w.state:""}}))})`, EvalTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := []ServiceWorker{}
+	err = json.Unmarshal([]byte(raw), &workers)
+	return workers, err
+}
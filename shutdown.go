@@ -0,0 +1,65 @@
+package headlessChrome
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// liveSessions holds every session that has been started and not yet
+// closed so they can be cleaned up if the program is signaled
+var liveSessions = make(map[*ChromeSession]struct{})
+var liveSessionsLock sync.Mutex
+
+// trackSession adds a session to the live sessions list
+func trackSession(cs *ChromeSession) {
+	liveSessionsLock.Lock()
+	defer liveSessionsLock.Unlock()
+	liveSessions[cs] = struct{}{}
+}
+
+// untrackSession removes a session from the live sessions list
+func untrackSession(cs *ChromeSession) {
+	liveSessionsLock.Lock()
+	defer liveSessionsLock.Unlock()
+	delete(liveSessions, cs)
+}
+
+// CloseAll force closes every chrome session started by this package
+// that has not already been closed
+func CloseAll() {
+	liveSessionsLock.Lock()
+	sessions := make([]*ChromeSession, 0, len(liveSessions))
+	for cs := range liveSessions {
+		sessions = append(sessions, cs)
+	}
+	liveSessionsLock.Unlock()
+
+	for _, cs := range sessions {
+		cs.ForceClose()
+	}
+}
+
+// RegisterShutdownOnSignal installs a signal handler that closes all live
+// chrome sessions and then exits the program.  By default it listens for
+// SIGINT and SIGTERM, but other signals can be supplied instead.  Without
+// this, chrome processes are orphaned when your program is killed.
+func RegisterShutdownOnSignal(signals ...os.Signal) {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, signals...)
+	go shutdownOnSignal(sigChan, os.Exit)
+}
+
+// shutdownOnSignal waits for a signal, closes all live sessions and then
+// exits with exit
+func shutdownOnSignal(sigChan <-chan os.Signal, exit func(int)) {
+	sig := <-sigChan
+	debug("Received signal", sig, "- closing all chrome sessions")
+	CloseAll()
+	exit(1)
+}
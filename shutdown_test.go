@@ -0,0 +1,79 @@
+package headlessChrome
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// isTracked reports whether a session is in the live sessions list
+func isTracked(cs *ChromeSession) bool {
+	liveSessionsLock.Lock()
+	defer liveSessionsLock.Unlock()
+	_, ok := liveSessions[cs]
+	return ok
+}
+
+// TestUntrackOnOutputEnd tests that a session whose chrome exits on its
+// own, without Exit being called, is no longer tracked
+func TestUntrackOnOutputEnd(t *testing.T) {
+	fake := newFakeConsole(nil)
+	cs := newFakeSession(t, fake)
+	trackSession(cs)
+
+	fake.end()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := cs.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if isTracked(cs) {
+		t.Fatal("session is still tracked after its output ended")
+	}
+}
+
+// TestCloseAll tests that every live session is force closed and
+// untracked
+func TestCloseAll(t *testing.T) {
+	fakes := []*fakeConsole{newFakeConsole(nil), newFakeConsole(nil)}
+	var sessions []*ChromeSession
+	for _, fake := range fakes {
+		cs := newFakeSession(t, fake)
+		trackSession(cs)
+		sessions = append(sessions, cs)
+	}
+
+	CloseAll()
+	for i, fake := range fakes {
+		fake.mu.Lock()
+		kills := fake.kills
+		fake.mu.Unlock()
+		if kills != 1 {
+			t.Errorf("session %d was killed %d times, want once", i, kills)
+		}
+		if isTracked(sessions[i]) {
+			t.Errorf("session %d is still tracked after CloseAll", i)
+		}
+	}
+}
+
+// TestShutdownOnSignal tests that a signal closes live sessions before
+// exiting
+func TestShutdownOnSignal(t *testing.T) {
+	fake := newFakeConsole(nil)
+	cs := newFakeSession(t, fake)
+	trackSession(cs)
+
+	sigChan := make(chan os.Signal, 1)
+	sigChan <- os.Interrupt
+	exitCode := -1
+	shutdownOnSignal(sigChan, func(code int) { exitCode = code })
+
+	if exitCode != 1 {
+		t.Fatalf("exit code = %d, want 1", exitCode)
+	}
+	if fake.kills != 1 || isTracked(cs) {
+		t.Fatalf("session was not closed before exiting: kills = %d, tracked = %v", fake.kills, isTracked(cs))
+	}
+}
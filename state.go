@@ -0,0 +1,85 @@
+package headlessChrome
+
+// SessionState is a stage in the life of a session.  Sessions start in
+// StateStarting and end in StateDead.
+type SessionState int
+
+const (
+	// StateStarting is chrome launching and printing its console banner
+	StateStarting SessionState = iota
+	// StateReady is the console waiting for input
+	StateReady
+	// StateEvaluating is an Eval waiting for chrome to print its result
+	StateEvaluating
+	// StateExiting is Exit or ForceClose stopping chrome
+	StateExiting
+	// StateDead is chrome's console output having ended.  No further
+	// transitions happen.
+	StateDead
+)
+
+// String implements fmt.Stringer
+func (s SessionState) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateReady:
+		return "ready"
+	case StateEvaluating:
+		return "evaluating"
+	case StateExiting:
+		return "exiting"
+	case StateDead:
+		return "dead"
+	}
+	return "unknown"
+}
+
+// WithStateHook calls hook each time the session moves from one state to
+// another.  Hooks are called one at a time, in order, from whichever
+// goroutine caused the transition, so they should return quickly and must
+// not call back into the session.
+func WithStateHook(hook func(from, to SessionState)) Option {
+	return func(o *options) {
+		o.stateHook = hook
+	}
+}
+
+// State returns the current state of the session
+func (cs *ChromeSession) State() SessionState {
+	cs.stateLock.Lock()
+	defer cs.stateLock.Unlock()
+	return cs.state
+}
+
+// setState moves the session to a new state and calls the state hook.
+// Nothing happens once the session is dead.
+func (cs *ChromeSession) setState(to SessionState) {
+	cs.stateLock.Lock()
+	defer cs.stateLock.Unlock()
+	cs.moveState(to)
+}
+
+// transitionState moves the session to a new state only if it is still
+// in the expected one, so an eval finishing does not mark a session that
+// exited meanwhile as ready again
+func (cs *ChromeSession) transitionState(from, to SessionState) {
+	cs.stateLock.Lock()
+	defer cs.stateLock.Unlock()
+	if cs.state == from {
+		cs.moveState(to)
+	}
+}
+
+// moveState changes the state with stateLock held
+func (cs *ChromeSession) moveState(to SessionState) {
+	from := cs.state
+	if from == to || from == StateDead {
+		return
+	}
+	cs.state = to
+	cs.debug("state changed from", from, "to", to)
+	if cs.stateHook != nil {
+		cs.stateHook(from, to)
+	}
+}
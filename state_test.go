@@ -0,0 +1,40 @@
+package headlessChrome
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/integrii/interactive"
+)
+
+// TestStateHook tests that the hook sees each transition of a session's
+// output ending
+func TestStateHook(t *testing.T) {
+	var transitions []string
+	raw := make(chan string, 5)
+	cs := &ChromeSession{
+		console:     interactiveConsole{&interactive.Session{Output: raw}},
+		Output:      make(chan string, 5),
+		closeOutput: true,
+		ready:       make(chan struct{}),
+		done:        make(chan struct{}),
+		stateHook: func(from, to SessionState) {
+			transitions = append(transitions, from.String()+"->"+to.String())
+		},
+	}
+	raw <- expectedFirstLine
+	close(raw)
+	cs.outputSanitizer()
+
+	// transitions from a dead session are ignored
+	cs.transitionState(StateReady, StateEvaluating)
+	cs.setState(StateExiting)
+
+	want := []string{"starting->ready", "ready->dead"}
+	if !reflect.DeepEqual(transitions, want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	if cs.State() != StateDead {
+		t.Fatalf("State() = %v, want %v", cs.State(), StateDead)
+	}
+}
@@ -0,0 +1,14 @@
+package headlessChrome
+
+// ClearStorage clears localStorage, sessionStorage, IndexedDB databases
+// and cache storage for the origin of the current page.  It waits up to
+// EvalTimeout for the databases and caches to be deleted.
+func (cs *ChromeSession) ClearStorage() error {
+	_, err := cs.EvalAsync(`(async function(){localStorage.clear();sessionStorage.clear();`+
+		`if(window.indexedDB&&indexedDB.databases){var dbs=await indexedDB.databases();`+
+		`await Promise.all(dbs.map(function(d){return new Promise(function(resolve,reject){`+
+		`var req=indexedDB.deleteDatabase(d.name);req.onsuccess=resolve;req.onblocked=resolve;req.onerror=function(){reject(req.error)}})}))}`+
+		`if(window.caches){var keys=await caches.keys();await Promise.all(keys.map(function(k){return caches.delete(k)}))}`+
+		`return true})()`, EvalTimeout)
+	return err
+}
@@ -0,0 +1,52 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Target is a page, iframe, worker or other target open in chrome
+type Target struct {
+	ID                   string `json:"id"`
+	Type                 string `json:"type"`
+	Title                string `json:"title"`
+	URL                  string `json:"url"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// WithRemoteDebuggingPort starts chrome with its devtools http endpoint
+// listening on the supplied local port, which Targets needs
+func WithRemoteDebuggingPort(port int) Option {
+	return func(o *options) {
+		o.args = append(o.args, "--remote-debugging-port="+strconv.Itoa(port))
+		o.debuggingPort = port
+	}
+}
+
+// Targets lists the targets open in chrome, such as tabs opened by links
+// with target=_blank.  The session must be started with
+// WithRemoteDebuggingPort.  The console always evaluates in the page the
+// session was started with, so other targets can be listed but not
+// switched to.
+func (cs *ChromeSession) Targets() ([]Target, error) {
+	if cs.debuggingPort == 0 {
+		return nil, errors.New("listing targets requires a session started WithRemoteDebuggingPort")
+	}
+
+	client := http.Client{Timeout: time.Second * 10}
+	resp, err := client.Get("http://127.0.0.1:" + strconv.Itoa(cs.debuggingPort) + "/json/list")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("devtools endpoint returned " + resp.Status)
+	}
+
+	var targets []Target
+	err = json.NewDecoder(resp.Body).Decode(&targets)
+	return targets, err
+}
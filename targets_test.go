@@ -0,0 +1,33 @@
+package headlessChrome
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// TestTargets tests listing targets from a devtools http endpoint
+func TestTargets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json/list" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`[{"id":"ABC","type":"page","title":"Example","url":"https://example.com/"}]`))
+	}))
+	defer srv.Close()
+
+	u, _ := url.Parse(srv.URL)
+	port, _ := strconv.Atoi(u.Port())
+	cs := &ChromeSession{debuggingPort: port}
+
+	targets, err := cs.Targets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0].ID != "ABC" || targets[0].URL != "https://example.com/" {
+		t.Fatalf("Targets() = %+v", targets)
+	}
+}
@@ -0,0 +1,67 @@
+package headlessChrome
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+)
+
+// traceRecord is one evaluation written by WithTrace
+type traceRecord struct {
+	Time       time.Time `json:"time"`
+	Session    string    `json:"session"`
+	Input      string    `json:"input"`
+	Type       string    `json:"type,omitempty"`
+	Output     string    `json:"output,omitempty"`
+	Logs       []string  `json:"logs,omitempty"`
+	DurationMs float64   `json:"durationMs"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// WithTrace writes a JSON line to w for every expression the session
+// evaluates, pairing the input with its result, the other output it
+// printed, how long it took and any error.  Each record is written with a
+// single call to Write, so a writer shared by several sessions must be
+// safe for concurrent use.  Evaluations that stream their output, such as
+// EvalCollect, only record the input, duration and error.
+func WithTrace(w io.Writer) Option {
+	return func(o *options) {
+		o.trace = w
+	}
+}
+
+// traceEval writes a trace record for an evaluation if the session was
+// started WithTrace
+func (cs *ChromeSession) traceEval(start time.Time, expr string, result *REPLResult, logs []string, err error) {
+	if cs.trace == nil {
+		return
+	}
+
+	record := traceRecord{
+		Time:       start,
+		Session:    cs.name,
+		Input:      expr,
+		Logs:       logs,
+		DurationMs: float64(time.Since(start)) / float64(time.Millisecond),
+	}
+	if result != nil {
+		record.Type = result.Type
+		record.Output = result.Value
+		if result.Err != nil {
+			err = result.Err
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+
+	b, err := json.Marshal(record)
+	if err != nil {
+		cs.debug("WARNING: failed to encode trace record:", err)
+		return
+	}
+	_, err = cs.trace.Write(append(b, '\n'))
+	if err != nil {
+		cs.debug("WARNING: failed to write trace record:", err)
+	}
+}
@@ -0,0 +1,37 @@
+package headlessChrome
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestTraceEval tests that evaluations are written as JSON lines
+func TestTraceEval(t *testing.T) {
+	var buf bytes.Buffer
+	cs := &ChromeSession{name: "tracer", trace: &buf}
+	cs.traceEval(time.Now(), "document.title", &REPLResult{Type: "string", Value: "Example"}, []string{"log line"}, nil)
+	cs.traceEval(time.Now(), "missing()", &REPLResult{Type: "object", Err: errors.New("ReferenceError: missing is not defined")}, nil, nil)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("wrote %d records, want 2: %s", len(lines), buf.String())
+	}
+
+	var record traceRecord
+	err := json.Unmarshal(lines[0], &record)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record.Session != "tracer" || record.Input != "document.title" || record.Output != "Example" || len(record.Logs) != 1 || record.Error != "" {
+		t.Fatalf("first record = %+v", record)
+	}
+
+	record = traceRecord{}
+	json.Unmarshal(lines[1], &record)
+	if record.Error != "ReferenceError: missing is not defined" {
+		t.Fatalf("second record = %+v, want the thrown error", record)
+	}
+}
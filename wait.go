@@ -0,0 +1,134 @@
+package headlessChrome
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pollInterval returns how often the session checks the page while waiting
+func (cs *ChromeSession) pollInterval() time.Duration {
+	if cs.poll > 0 {
+		return cs.poll
+	}
+	return PollInterval
+}
+
+// WaitForCondition polls a boolean javascript expression, such as
+// "window.appReady === true", until it is true.  The error returned on
+// timeout includes the expression.
+func (cs *ChromeSession) WaitForCondition(jsExpr string, timeout time.Duration) error {
+	return cs.waitForCondition(jsExpr, cs.pollInterval(), timeout)
+}
+
+// waitForCondition polls a boolean javascript expression every interval
+// until it is true or the timeout expires
+func (cs *ChromeSession) waitForCondition(expr string, interval, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		ok, err := cs.EvalBool(expr)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("timed out after %v waiting for %s", timeout, expr)
+		}
+		time.Sleep(interval)
+	}
+}
+
+// WaitForNetworkIdle waits until the page has loaded and no network
+// request has finished for idleMs milliseconds.  The chrome console can
+// not see devtools network events, so this uses the page's resource
+// timing entries.  Those only record requests once they finish, so a
+// single request that is still in flight, such as a long poll, is not
+// detected.
+func (cs *ChromeSession) WaitForNetworkIdle(idleMs int, timeout time.Duration) error {
+	return cs.waitForCondition(`(function(){performance.setResourceTimingBufferSize(100000);var last=0;`+
+		`performance.getEntriesByType("resource").forEach(function(e){if(e.responseEnd>last){last=e.responseEnd}});`+
+		`return document.readyState==="complete"&&performance.now()-last>=`+strconv.Itoa(idleMs)+`})()`, cs.pollInterval(), timeout)
+}
+
+// WaitForOutput reads output from the session until a line matches and
+// returns that line.  Lines that do not match are discarded.  An error is
+// returned if nothing matches before the timeout or the session closes.
+func (cs *ChromeSession) WaitForOutput(match func(string) bool, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	line, err := cs.WaitForOutputContext(ctx, match)
+	if err == context.DeadlineExceeded {
+		return "", fmt.Errorf("timed out after %v waiting for matching output", timeout)
+	}
+	return line, err
+}
+
+// WaitForOutputContext works like WaitForOutput, but waits until the
+// context is done instead of for a timeout, returning the context's error
+func (cs *ChromeSession) WaitForOutputContext(ctx context.Context, match func(string) bool) (string, error) {
+	for {
+		line, err := cs.ReadLine(ctx)
+		if err != nil {
+			return "", err
+		}
+		if match(line) {
+			return line, nil
+		}
+	}
+}
+
+// WaitForText waits for a line of output containing substr and returns
+// it.  This pairs well with console.log markers written by page scripts.
+func (cs *ChromeSession) WaitForText(substr string, timeout time.Duration) (string, error) {
+	line, err := cs.WaitForOutput(func(line string) bool {
+		return strings.Contains(line, substr)
+	}, timeout)
+	if err != nil {
+		return "", fmt.Errorf("waiting for %q: %w", substr, err)
+	}
+	return line, nil
+}
+
+// WaitForSelector waits until an element matching the selector exists on
+// the page
+func (cs *ChromeSession) WaitForSelector(selector string, timeout time.Duration) error {
+	return cs.waitForCondition(`document.querySelector(`+jsString(selector)+`) !== null`, cs.pollInterval(), timeout)
+}
+
+// WaitForCount waits until at least atLeast elements match the selector,
+// such as the items of a lazy loaded list, and returns how many matched
+// when it last checked.  On timeout the count so far is returned with the
+// error.
+func (cs *ChromeSession) WaitForCount(selector string, atLeast int, timeout time.Duration) (int, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		count, err := cs.EvalNumber(`document.querySelectorAll(` + jsString(selector) + `).length`)
+		if err != nil {
+			return 0, err
+		}
+		if int(count) >= atLeast {
+			return int(count), nil
+		}
+		if time.Now().After(deadline) {
+			return int(count), fmt.Errorf("timed out after %v waiting for %d elements matching %s, found %d", timeout, atLeast, selector, int(count))
+		}
+		time.Sleep(cs.pollInterval())
+	}
+}
+
+// WaitForRegex waits for a line of output matching the regular expression
+// and returns the match and its submatches, as FindStringSubmatch does.
+// This is handy for pulling a value, like a token, out of a page's logs.
+func (cs *ChromeSession) WaitForRegex(re *regexp.Regexp, timeout time.Duration) ([]string, error) {
+	line, err := cs.WaitForOutput(re.MatchString, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("waiting for pattern %s: %w", re, err)
+	}
+	return re.FindStringSubmatch(line), nil
+}
@@ -0,0 +1,57 @@
+package headlessChrome
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestWaitForText tests waiting for a line containing some text
+func TestWaitForText(t *testing.T) {
+	cs := &ChromeSession{Output: make(chan string, 3)}
+	cs.Output <- "loading"
+	cs.Output <- "marker: step one done"
+	cs.Output <- "after"
+
+	line, err := cs.WaitForText("step one", time.Second)
+	if err != nil || line != "marker: step one done" {
+		t.Fatalf("WaitForText() = %q, %v", line, err)
+	}
+
+	_, err = cs.WaitForText("never printed", time.Millisecond*50)
+	if err == nil {
+		t.Fatal("WaitForText() did not time out")
+	}
+}
+
+// TestWaitForRegex tests extracting submatches from output
+func TestWaitForRegex(t *testing.T) {
+	cs := &ChromeSession{Output: make(chan string, 2)}
+	cs.Output <- "loading"
+	cs.Output <- "session token=abc123"
+
+	match, err := cs.WaitForRegex(regexp.MustCompile(`token=(\w+)`), time.Second)
+	if err != nil || len(match) != 2 || match[1] != "abc123" {
+		t.Fatalf("WaitForRegex() = %v, %v", match, err)
+	}
+}
+
+// TestWaitForOutputContext tests that waiting stops when the context is
+// canceled
+func TestWaitForOutputContext(t *testing.T) {
+	cs := &ChromeSession{Output: make(chan string, 1)}
+	cs.Output <- "ready"
+
+	line, err := cs.WaitForOutputContext(context.Background(), func(l string) bool { return l == "ready" })
+	if err != nil || line != "ready" {
+		t.Fatalf("WaitForOutputContext() = %q, %v", line, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = cs.WaitForOutputContext(ctx, func(string) bool { return true })
+	if err != context.Canceled {
+		t.Fatalf("WaitForOutputContext() with canceled context err = %v, want %v", err, context.Canceled)
+	}
+}